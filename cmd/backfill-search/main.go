@@ -0,0 +1,79 @@
+// Command backfill-search fills in the folded search fields of products
+// created before full-text search existed, or whose fields no longer match
+// their name and description. Products without them do not show up in
+// search results.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"product-service/config"
+	"product-service/internal/product"
+	"time"
+
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the products that would change without writing")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.LoadConfig()
+
+	ctx := context.Background()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	productRepository := product.NewProductRepository(client.Database(cfg.MongoDB).Collection("products"))
+
+	if !*dryRun {
+		if err := productRepository.EnsureIndexes(ctx); err != nil {
+			log.Fatalf("Failed to create product indexes: %v", err)
+		}
+	}
+
+	var scanned, updated int
+
+	err = productRepository.IterateProducts(ctx, &product.ProductFilter{}, func(p *product.Product) error {
+
+		scanned++
+
+		search := product.NewProductSearch(p.ProductName, p.ProductDescription)
+		if p.Search != nil && *p.Search == *search {
+			return nil
+		}
+
+		updated++
+
+		if *dryRun {
+			log.Printf("Would update %s (%s)", p.ID.Hex(), p.ProductName)
+			return nil
+		}
+
+		return productRepository.UpdateSearch(ctx, p.ID, search)
+	})
+	if err != nil {
+		log.Fatalf("Backfill stopped after %d products: %v", scanned, err)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d of %d products would be updated", updated, scanned)
+		return
+	}
+
+	log.Printf("Updated %d of %d products", updated, scanned)
+}
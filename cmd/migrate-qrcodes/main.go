@@ -0,0 +1,188 @@
+// Command migrate-qrcodes rewrites the stored QR code of every product into
+// the format the current configuration produces, typically to move existing
+// products to signed codes once QR_SIGNED and QR_SIGNING_KEY are set.
+//
+// Every rewrite is reported like a product update made through the API: a
+// ProductUpdated event goes to the outbox when KAFKA_BROKERS is set, and the
+// migrated products are reindexed when ELASTIC_URL is set.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"product-service/config"
+	"product-service/internal/events"
+	"product-service/internal/folder"
+	"product-service/internal/outbox"
+	"product-service/internal/product"
+	"product-service/internal/promotion"
+	"product-service/internal/topic"
+	"product-service/pkg/elastic"
+	"product-service/pkg/mongotx"
+	"product-service/pkg/qr"
+	"product-service/pkg/uploader"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// reindexBatchSize bounds the products read back and indexed per request
+// once the migration is done.
+const reindexBatchSize = 500
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "report the products that would change without writing")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.LoadConfig()
+
+	if !cfg.QR.Signed {
+		log.Fatal("QR_SIGNED is not enabled, nothing to migrate")
+	}
+
+	signer, err := qr.NewSigner(cfg.QR.SigningKey, cfg.QR.Signed, cfg.QR.LegacyUntil)
+	if err != nil {
+		log.Fatalf("Failed to initialize QR signer: %v", err)
+	}
+
+	ctx := context.Background()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	database := client.Database(cfg.MongoDB)
+
+	productRepository := product.NewProductRepository(database.Collection("products"))
+
+	var listeners []product.ChangeListener
+
+	// The event is stored in the outbox together with the new code, as the
+	// service does, and the running server's relay publishes it.
+	transactions := mongotx.NewDirectRunner()
+
+	if len(cfg.Events.Brokers) > 0 {
+		supported, err := mongotx.Supported(ctx, client)
+		if err != nil {
+			log.Fatalf("Failed to check MongoDB transaction support: %v", err)
+		}
+		if !supported {
+			log.Fatal("KAFKA_BROKERS is set but MongoDB is not a replica set: the event outbox needs transactions")
+		}
+		transactions = mongotx.NewRunner(client)
+
+		outboxRepository := outbox.NewRepository(database.Collection("event_outbox"), database.Collection("outbox_leases"))
+		emitter := events.NewEmitter(outbox.NewPublisher(outboxRepository), events.Topics{
+			Product: cfg.Events.ProductTopic,
+			Folder:  cfg.Events.FolderTopic,
+		})
+		listeners = append(listeners, events.NewListener(emitter, productRepository))
+	} else {
+		log.Println("KAFKA_BROKERS is not set, no product events will be emitted")
+	}
+
+	var projection *product.ElasticProjection
+
+	if cfg.Search.ElasticUrl != "" {
+		// The command only calls other services, so it talks to Consul
+		// without registering itself.
+		consulClient, err := api.NewClient(&api.Config{Address: fmt.Sprintf("%s:%s", cfg.Consul.Host, cfg.Consul.Port)})
+		if err != nil {
+			log.Fatalf("Failed to create Consul client: %v", err)
+		}
+
+		folderRepository := folder.NewFolderRepository(database.Collection("folders"))
+		promotionRepository := promotion.NewPromotionRepository(database.Collection("promotions"))
+		redemptionRepository := promotion.NewRedemptionRepository(database.Collection("promotion_redemptions"))
+		promotionService := promotion.NewPromotionService(promotionRepository, redemptionRepository, productRepository, folderRepository, mongotx.NewDirectRunner())
+
+		productEnricher := product.NewProductEnricher(folderRepository, topic.NewTopicService(consulClient), uploader.NewImageService(consulClient), promotionService)
+
+		elasticClient := elastic.NewClient(cfg.Search.ElasticUrl, cfg.Search.ElasticUsername, cfg.Search.ElasticPassword)
+		projection = product.NewElasticProjection(elasticClient, cfg.Search.ElasticIndex, productRepository, folderRepository, productEnricher, cfg.Media.ServiceToken, 0)
+	} else {
+		log.Println("ELASTIC_URL is not set, the search index will not be updated")
+	}
+
+	var scanned int
+	var migrated []primitive.ObjectID
+
+	err = productRepository.IterateProducts(ctx, &product.ProductFilter{}, func(p *product.Product) error {
+
+		scanned++
+
+		qrCode := signer.ProductPayload(p.ID.Hex())
+		if p.QRCode == qrCode {
+			return nil
+		}
+
+		if *dryRun {
+			migrated = append(migrated, p.ID)
+			log.Printf("Would update %s: %q -> %q", p.ID.Hex(), p.QRCode, qrCode)
+			return nil
+		}
+
+		err := transactions.WithTransaction(ctx, func(ctx context.Context) error {
+
+			if err := productRepository.UpdateQRCode(ctx, p.ID, qrCode); err != nil {
+				return err
+			}
+
+			change := product.ProductChange{ID: p.ID, Type: product.ChangeUpdated}
+			for _, listener := range listeners {
+				if err := listener.ProductChanged(ctx, change); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		migrated = append(migrated, p.ID)
+
+		return nil
+	})
+	if err != nil {
+		log.Printf("Migration stopped after %d products: %v", scanned, err)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d of %d products would be updated", len(migrated), scanned)
+		return
+	}
+
+	// Reindex what was migrated even when the migration stopped early, so the
+	// index matches the products already rewritten.
+	if projection != nil {
+		for start := 0; start < len(migrated); start += reindexBatchSize {
+			end := min(start+reindexBatchSize, len(migrated))
+			if syncErr := projection.Sync(ctx, migrated[start:end]); syncErr != nil {
+				log.Fatalf("Reindexing stopped after %d of %d migrated products, run cmd/reindex: %v", start, len(migrated), syncErr)
+			}
+		}
+	}
+
+	if err != nil {
+		log.Fatalf("Updated %d of %d products before the migration stopped", len(migrated), scanned)
+	}
+
+	log.Printf("Updated %d of %d products", len(migrated), scanned)
+}
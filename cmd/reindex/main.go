@@ -0,0 +1,81 @@
+// Command reindex rebuilds the Elasticsearch product index from MongoDB. It
+// creates the index when missing, indexes every product with its folder
+// path, topic name and effective prices, and removes documents of products
+// that no longer exist.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"product-service/config"
+	"product-service/internal/folder"
+	"product-service/internal/product"
+	"product-service/internal/promotion"
+	"product-service/internal/topic"
+	"product-service/pkg/elastic"
+	"product-service/pkg/mongotx"
+	"product-service/pkg/uploader"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/joho/godotenv"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
+	}
+
+	cfg := config.LoadConfig()
+
+	if cfg.Search.ElasticUrl == "" {
+		log.Fatal("ELASTIC_URL is not set, nothing to reindex")
+	}
+
+	if cfg.Media.ServiceToken == "" {
+		log.Println("SERVICE_TOKEN is not set, topic names and image urls will be left empty")
+	}
+
+	ctx := context.Background()
+
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(connectCtx, options.Client().ApplyURI(cfg.MongoURI))
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer client.Disconnect(ctx)
+
+	// The command only calls other services, so it talks to Consul without
+	// registering itself.
+	consulClient, err := api.NewClient(&api.Config{Address: fmt.Sprintf("%s:%s", cfg.Consul.Host, cfg.Consul.Port)})
+	if err != nil {
+		log.Fatalf("Failed to create Consul client: %v", err)
+	}
+
+	database := client.Database(cfg.MongoDB)
+
+	productRepository := product.NewProductRepository(database.Collection("products"))
+	folderRepository := folder.NewFolderRepository(database.Collection("folders"))
+	promotionRepository := promotion.NewPromotionRepository(database.Collection("promotions"))
+	redemptionRepository := promotion.NewRedemptionRepository(database.Collection("promotion_redemptions"))
+	promotionService := promotion.NewPromotionService(promotionRepository, redemptionRepository, productRepository, folderRepository, mongotx.NewDirectRunner())
+
+	productEnricher := product.NewProductEnricher(folderRepository, topic.NewTopicService(consulClient), uploader.NewImageService(consulClient), promotionService)
+
+	elasticClient := elastic.NewClient(cfg.Search.ElasticUrl, cfg.Search.ElasticUsername, cfg.Search.ElasticPassword)
+	projection := product.NewElasticProjection(elasticClient, cfg.Search.ElasticIndex, productRepository, folderRepository, productEnricher, cfg.Media.ServiceToken, 0)
+
+	started := time.Now()
+
+	count, err := projection.Reindex(ctx)
+	if err != nil {
+		log.Fatalf("Reindex stopped after %d products: %v", count, err)
+	}
+
+	log.Printf("Indexed %d products into %s in %s", count, cfg.Search.ElasticIndex, time.Since(started).Round(time.Millisecond))
+}
@@ -7,10 +7,23 @@ import (
 	"os"
 	"os/signal"
 	"product-service/config"
+	"product-service/internal/events"
 	"product-service/internal/folder"
+	"product-service/internal/importer"
+	"product-service/internal/media"
+	"product-service/internal/middleware"
+	"product-service/internal/outbox"
 	"product-service/internal/product"
+	"product-service/internal/promotion"
+	"product-service/internal/qrcode"
 	"product-service/internal/topic"
+	"product-service/internal/usage"
+	"product-service/internal/user"
+	"product-service/pkg/auth"
 	"product-service/pkg/consul"
+	"product-service/pkg/elastic"
+	"product-service/pkg/mongotx"
+	"product-service/pkg/qr"
 	"product-service/pkg/uploader"
 	"product-service/pkg/zap"
 	"syscall"
@@ -35,6 +48,16 @@ func main() {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
+	verifier, err := auth.NewVerifier(cfg.JWT)
+	if err != nil {
+		logger.Fatalf("Failed to initialize JWT verifier: %v", err)
+	}
+
+	qrSigner, err := qr.NewSigner(cfg.QR.SigningKey, cfg.QR.Signed, cfg.QR.LegacyUntil)
+	if err != nil {
+		logger.Fatalf("Failed to initialize QR signer: %v", err)
+	}
+
 	consulConn := consul.NewConsulConn(logger, cfg)
 	consulClient := consulConn.Connect()
 	defer consulConn.Deregister()
@@ -50,24 +73,173 @@ func main() {
 		}
 	}()
 
+	policy, err := loadPolicy(cfg.PolicyFile)
+	if err != nil {
+		logger.Fatalf("Failed to load authorization policy: %v", err)
+	}
+
+	userService := user.NewUserService(consulClient)
+	authorizer := middleware.NewAuthorizer(policy, userService)
+
 	topicService := topic.NewTopicService(consulClient)
 
 	imageService := uploader.NewImageService(consulClient)
 
+	transactionsSupported, err := mongotx.Supported(context.Background(), mongoClient)
+	if err != nil {
+		logger.Fatalf("Failed to check MongoDB transaction support: %v", err)
+	}
+
+	// Folder moves and deletes and promotion redemptions update several
+	// documents and run in a transaction when the server can run one.
+	transactions := mongotx.NewDirectRunner()
+	if transactionsSupported {
+		transactions = mongotx.NewRunner(mongoClient)
+	} else {
+		logger.Warn("MongoDB is not a replica set, folder moves and deletes and promotion redemptions will not be atomic")
+	}
+
+	productCollection := mongoClient.Database((cfg.MongoDB)).Collection("products")
+	productRepository := product.NewProductRepository(productCollection)
+	if err := productRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("Failed to create product indexes: %v", err)
+	}
+
+	deletionCollection := mongoClient.Database(cfg.MongoDB).Collection("image_deletions")
+	deletionRepository := media.NewDeletionRepository(deletionCollection)
+	if err := deletionRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("Failed to create image deletion indexes: %v", err)
+	}
+	imageLifecycle := media.NewImageLifecycle(imageService, deletionRepository, productRepository, cfg.Media.ServiceToken)
+
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	// Deletions that fail during a request are queued, and only the worker
+	// drains the queue. It calls the media store with the service token, so
+	// without one the queue is kept until a restart with a token.
+	if cfg.Media.ServiceToken == "" {
+		logger.Warn("SERVICE_TOKEN is not set, queued image deletions will not be retried")
+	} else {
+		deletionWorker := media.NewDeletionWorker(deletionRepository, imageService, productRepository, cfg.Media.ServiceToken, cfg.Media.DeleteRetryInterval)
+		go deletionWorker.Run(workerCtx)
+	}
+
 	folderCollection := mongoClient.Database(cfg.MongoDB).Collection("folders")
 	folderRepository := folder.NewFolderRepository(folderCollection)
-	folderService := folder.NewFolderService(folderRepository)
+	if err := folderRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("Failed to create folder indexes: %v", err)
+	}
+	var folderListeners []folder.ChangeListener
+	var productListeners []product.ChangeListener
+
+	var outboxHandler *outbox.OutboxHandler
+
+	// Product writes only need a transaction to store their events in the
+	// outbox together with the change.
+	productTransactions := mongotx.NewDirectRunner()
+
+	if len(cfg.Events.Brokers) > 0 {
+		if !transactionsSupported {
+			logger.Fatalf("KAFKA_BROKERS is set but MongoDB is not a replica set: the event outbox needs transactions")
+		}
+		productTransactions = transactions
+
+		publisher := events.NewKafkaPublisher(cfg.Events.Brokers)
+		defer publisher.Close()
+
+		outboxCollection := mongoClient.Database(cfg.MongoDB).Collection("event_outbox")
+		leaseCollection := mongoClient.Database(cfg.MongoDB).Collection("outbox_leases")
+		outboxRepository := outbox.NewRepository(outboxCollection, leaseCollection)
+		if err := outboxRepository.EnsureIndexes(context.Background(), cfg.Events.OutboxRetention); err != nil {
+			logger.Fatalf("Failed to create outbox indexes: %v", err)
+		}
+
+		relay := outbox.NewRelay(outboxRepository, publisher, cfg.Events.OutboxPollInterval)
+		go relay.Run(workerCtx)
+
+		outboxService := outbox.NewOutboxService(outboxRepository, relay)
+		outboxHandler = outbox.NewOutboxHandler(outboxService)
+
+		emitter := events.NewEmitter(outbox.NewPublisher(outboxRepository), events.Topics{
+			Product: cfg.Events.ProductTopic,
+			Folder:  cfg.Events.FolderTopic,
+		})
+		eventListener := events.NewListener(emitter, productRepository)
+
+		folderListeners = append(folderListeners, eventListener)
+		productListeners = append(productListeners, eventListener)
+	} else {
+		logger.Warn("KAFKA_BROKERS is not set, domain events are disabled")
+	}
+
+	promotionCollection := mongoClient.Database(cfg.MongoDB).Collection("promotions")
+	promotionRepository := promotion.NewPromotionRepository(promotionCollection)
+	redemptionCollection := mongoClient.Database(cfg.MongoDB).Collection("promotion_redemptions")
+	redemptionRepository := promotion.NewRedemptionRepository(redemptionCollection)
+	if err := redemptionRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("Failed to create promotion redemption indexes: %v", err)
+	}
+	promotionService := promotion.NewPromotionService(promotionRepository, redemptionRepository, productRepository, folderRepository, transactions)
+	promotionHandler := promotion.NewPromotionHandler(promotionService)
+
+	productEnricher := product.NewProductEnricher(folderRepository, topicService, imageService, promotionService)
+
+	var searchBackend product.SearchBackend = productRepository
+
+	if cfg.Search.ElasticUrl != "" {
+		elasticClient := elastic.NewClient(cfg.Search.ElasticUrl, cfg.Search.ElasticUsername, cfg.Search.ElasticPassword)
+		projection := product.NewElasticProjection(elasticClient, cfg.Search.ElasticIndex, productRepository, folderRepository, productEnricher, cfg.Media.ServiceToken, cfg.Search.ReindexInterval)
+		if err := projection.EnsureIndex(context.Background()); err != nil {
+			logger.Fatalf("Failed to create Elasticsearch index: %v", err)
+		}
+		go projection.Run(workerCtx)
+
+		productListeners = append(productListeners, projection)
+		folderListeners = append(folderListeners, projection)
+		if cfg.Search.Backend == "elastic" {
+			searchBackend = projection
+		}
+	} else if cfg.Search.Backend == "elastic" {
+		logger.Fatalf("SEARCH_BACKEND is elastic but ELASTIC_URL is not set")
+	}
+
+	folderProducts := product.NewFolderProductStore(productRepository, productListeners...)
+	folderService := folder.NewFolderService(folderRepository, folderProducts, transactions, imageLifecycle, folderListeners...)
 	folderHandler := folder.NewFolderHandler(folderService)
 
-	productCollection := mongoClient.Database((cfg.MongoDB)).Collection("products")
-	productRepository := product.NewProductRepository(productCollection)
-	productService := product.NewProductService(productRepository, folderRepository, topicService, imageService)
+	productService := product.NewProductService(productRepository, productEnricher, imageLifecycle, folderRepository, qrSigner, searchBackend, productTransactions, productListeners...)
 	productHandler := product.NewProductHandler(productService)
 
+	importCollection := mongoClient.Database(cfg.MongoDB).Collection("import_jobs")
+	importRepository := importer.NewJobRepository(importCollection)
+	importService := importer.NewImportService(workerCtx, importRepository, productService, folderService)
+	importHandler := importer.NewImportHandler(importService)
+
+	qrcodeRegistry := qrcode.NewRegistry(qrSigner)
+	qrcodeRegistry.Register(qr.TypeProduct, qrcode.NewProductResolver(productService))
+	qrcodeHandler := qrcode.NewQRCodeHandler(qrcodeRegistry)
+
+	usageCollection := mongoClient.Database(cfg.MongoDB).Collection("usage_sessions")
+	usageRepository := usage.NewUsageRepository(usageCollection)
+	if err := usageRepository.EnsureIndexes(context.Background()); err != nil {
+		logger.Fatalf("Failed to create usage session indexes: %v", err)
+	}
+	usageService := usage.NewUsageService(usageRepository, productRepository)
+	usageHandler := usage.NewUsageHandler(usageService)
+
 	router := gin.Default()
 
-	folder.RegisterRoutes(router, folderHandler)
-	product.RegisterRoutes(router, productHandler)
+	folder.RegisterRoutes(router, folderHandler, verifier, authorizer)
+	product.RegisterRoutes(router, productHandler, verifier, authorizer)
+	promotion.RegisterRoutes(router, promotionHandler, verifier, authorizer)
+	usage.RegisterRoutes(router, usageHandler, verifier, authorizer)
+	qrcode.RegisterRoutes(router, qrcodeHandler, verifier, authorizer)
+	importer.RegisterRoutes(router, importHandler, verifier, authorizer)
+
+	if outboxHandler != nil {
+		outbox.RegisterRoutes(router, outboxHandler, verifier, authorizer)
+	}
 
 	server := &http.Server{
 		Addr:    ":" + cfg.Port,
@@ -88,6 +260,9 @@ func main() {
 
 	logger.Info("Shutting down server...")
 
+	stopWorkers()
+	importService.Wait()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
@@ -114,3 +289,10 @@ func connectToMongoDB(uri string) (*mongo.Client, error) {
 	log.Println("Successfully connected to MongoDB")
 	return client, nil
 }
+
+func loadPolicy(path string) (*auth.Policy, error) {
+	if path == "" {
+		return auth.ParsePolicy(config.DefaultPolicy)
+	}
+	return auth.LoadPolicy(path)
+}
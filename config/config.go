@@ -1,6 +1,12 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type Consul struct {
 	Host string `mapstructure:"host" validate:"required"`
@@ -47,6 +53,57 @@ type ZapConfig struct {
 	} `mapstructure:"cores"`
 }
 
+type JWTConfig struct {
+	Secret        string        `mapstructure:"secret"`
+	PublicKeyFile string        `mapstructure:"publicKeyFile"`
+	JWKSFile      string        `mapstructure:"jwksFile"`
+	Issuer        string        `mapstructure:"issuer"`
+	Audience      string        `mapstructure:"audience"`
+	Leeway        time.Duration `mapstructure:"leeway"`
+}
+
+type MediaConfig struct {
+	// ServiceToken authorizes media store calls made outside a user request,
+	// such as retrying queued image deletions.
+	ServiceToken        string        `mapstructure:"serviceToken"`
+	DeleteRetryInterval time.Duration `mapstructure:"deleteRetryInterval"`
+}
+
+type QRConfig struct {
+	SigningKey string `mapstructure:"signingKey"`
+	// Signed makes new QR codes carry an HMAC signature.
+	Signed bool `mapstructure:"signed"`
+	// LegacyUntil is the end of the grace period for unsigned codes. It is
+	// required when Signed is set; a past time rejects them right away.
+	LegacyUntil time.Time `mapstructure:"legacyUntil"`
+}
+
+type SearchConfig struct {
+	// Backend selects the engine behind product search: "mongo" or "elastic".
+	Backend         string `mapstructure:"backend"`
+	ElasticUrl      string `mapstructure:"elasticUrl"`
+	ElasticUsername string `mapstructure:"elasticUsername"`
+	ElasticPassword string `mapstructure:"elasticPassword"`
+	ElasticIndex    string `mapstructure:"elasticIndex"`
+	// ReindexInterval schedules a full reindex that picks up changes made
+	// outside the product service, such as folder renames and promotions
+	// starting or ending; zero disables it.
+	ReindexInterval time.Duration `mapstructure:"reindexInterval"`
+}
+
+type EventsConfig struct {
+	// Brokers lists the Kafka bootstrap servers; events are disabled when empty.
+	Brokers      []string `mapstructure:"brokers"`
+	ProductTopic string   `mapstructure:"productTopic"`
+	FolderTopic  string   `mapstructure:"folderTopic"`
+
+	// OutboxPollInterval is how often the relay looks for pending outbox
+	// entries; OutboxRetention is how long published entries are kept for
+	// replay.
+	OutboxPollInterval time.Duration `mapstructure:"outboxPollInterval"`
+	OutboxRetention    time.Duration `mapstructure:"outboxRetention"`
+}
+
 type Config struct {
 	Port     string
 	MongoURI string
@@ -55,6 +112,13 @@ type Config struct {
 	Registry Registry         `mapstructure:"registry" validate:"required"`
 	App      AppConfiguration `mapstructure:"app"`
 	Zap      ZapConfig        `mapstructure:"zap"`
+	JWT      JWTConfig        `mapstructure:"jwt"`
+	Media    MediaConfig      `mapstructure:"media"`
+	QR       QRConfig         `mapstructure:"qr"`
+	Search   SearchConfig     `mapstructure:"search"`
+	Events   EventsConfig     `mapstructure:"events"`
+	// PolicyFile points to a JSON role to permission mapping; DefaultPolicy is used when empty.
+	PolicyFile string `mapstructure:"policyFile"`
 }
 
 func LoadConfig() *Config {
@@ -69,6 +133,39 @@ func LoadConfig() *Config {
 		Registry: Registry{
 			Host: getEnv("REGISTRY_HOST", "localhost"),
 		},
+		PolicyFile: getEnv("POLICY_FILE", ""),
+		JWT: JWTConfig{
+			Secret:        getEnv("JWT_SECRET", ""),
+			PublicKeyFile: getEnv("JWT_PUBLIC_KEY_FILE", ""),
+			JWKSFile:      getEnv("JWT_JWKS_FILE", ""),
+			Issuer:        getEnv("JWT_ISSUER", ""),
+			Audience:      getEnv("JWT_AUDIENCE", ""),
+			Leeway:        getEnvDuration("JWT_LEEWAY", 30*time.Second),
+		},
+		Media: MediaConfig{
+			ServiceToken:        getEnv("SERVICE_TOKEN", ""),
+			DeleteRetryInterval: getEnvDuration("IMAGE_DELETE_RETRY_INTERVAL", time.Minute),
+		},
+		QR: QRConfig{
+			SigningKey:  getEnv("QR_SIGNING_KEY", ""),
+			Signed:      getEnvBool("QR_SIGNED", false),
+			LegacyUntil: getEnvTime("QR_LEGACY_UNTIL"),
+		},
+		Search: SearchConfig{
+			Backend:         getEnv("SEARCH_BACKEND", "mongo"),
+			ElasticUrl:      getEnv("ELASTIC_URL", ""),
+			ElasticUsername: getEnv("ELASTIC_USERNAME", ""),
+			ElasticPassword: getEnv("ELASTIC_PASSWORD", ""),
+			ElasticIndex:    getEnv("ELASTIC_INDEX", "products"),
+			ReindexInterval: getEnvDuration("ELASTIC_REINDEX_INTERVAL", 0),
+		},
+		Events: EventsConfig{
+			Brokers:            getEnvList("KAFKA_BROKERS"),
+			ProductTopic:       getEnv("PRODUCT_EVENTS_TOPIC", "product.events"),
+			FolderTopic:        getEnv("FOLDER_EVENTS_TOPIC", "folder.events"),
+			OutboxPollInterval: getEnvDuration("OUTBOX_POLL_INTERVAL", time.Second),
+			OutboxRetention:    getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		},
 		App: AppConfiguration{
 			API: APIConfig{
 				Rest: RestConfig{
@@ -99,6 +196,11 @@ func LoadConfig() *Config {
 			},
 		},
 	}
+
+	if config.QR.Signed && config.QR.LegacyUntil.IsZero() {
+		log.Fatalf("QR_LEGACY_UNTIL is required when QR_SIGNED is true; set it to a past time to reject unsigned codes right away")
+	}
+
 	return config
 }
 
@@ -108,3 +210,50 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvDuration, getEnvBool and getEnvTime stop the process on a malformed
+// value rather than falling back to the default, so a typo cannot silently
+// turn a setting off.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid %s %q: %v", key, value, err)
+		}
+		return d
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Fatalf("Invalid %s %q: %v", key, value, err)
+		}
+		return b
+	}
+	return defaultValue
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func getEnvTime(key string) time.Time {
+	if value, exists := os.LookupEnv(key); exists {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Fatalf("Invalid %s %q, expected RFC 3339: %v", key, value, err)
+		}
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,8 @@
+package config
+
+import _ "embed"
+
+// DefaultPolicy is the role to permission mapping used when POLICY_FILE is not set.
+//
+//go:embed policy.json
+var DefaultPolicy []byte
@@ -7,6 +7,8 @@ import (
 const (
 	ErrInvalidOperation = "ERR_INVALID_OPERATION"
 	ErrInvalidRequest   = "ERR_INVALID_REQUEST"
+	ErrUnauthorized     = "ERR_UNAUTHORIZED"
+	ErrForbidden        = "ERR_FORBIDDEN"
 )
 
 type APIResponse struct {
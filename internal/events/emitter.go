@@ -0,0 +1,65 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Topics names the topic each aggregate's events go to.
+type Topics struct {
+	Product string
+	Folder  string
+}
+
+// Emitter wraps payloads in the event envelope and publishes them keyed by
+// aggregate ID.
+type Emitter struct {
+	publisher Publisher
+	topics    Topics
+}
+
+func NewEmitter(publisher Publisher, topics Topics) *Emitter {
+	return &Emitter{
+		publisher: publisher,
+		topics:    topics,
+	}
+}
+
+func (e *Emitter) Emit(ctx context.Context, topic string, eventType string, version int, aggregateID string, data interface{}) error {
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        eventType,
+		Version:     version,
+		Source:      source,
+		AggregateID: aggregateID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        payload,
+	}
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return e.publisher.Publish(ctx, Message{
+		Topic: topic,
+		Key:   []byte(aggregateID),
+		Value: value,
+		Headers: map[string]string{
+			"content-type":  "application/json",
+			"event-type":    eventType,
+			"event-version": strconv.Itoa(version),
+			"event-schema":  SchemaID(eventType, version),
+		},
+	})
+}
@@ -0,0 +1,80 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ProductCreated = "ProductCreated"
+	ProductUpdated = "ProductUpdated"
+	ProductDeleted = "ProductDeleted"
+	FolderCreated  = "FolderCreated"
+	FolderMoved    = "FolderMoved"
+	FolderDeleted  = "FolderDeleted"
+
+	source = "product-service"
+)
+
+// Event is the envelope every message carries, described by
+// schemas/envelope.v1.json. Data holds the payload of Type at Version.
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Version     int             `json:"version"`
+	Source      string          `json:"source"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// ProductV1 is the payload of ProductCreated and ProductUpdated.
+type ProductV1 struct {
+	ID                 string    `json:"id"`
+	ProductName        string    `json:"product_name"`
+	ProductDescription string    `json:"product_description"`
+	FolderID           string    `json:"folder_id"`
+	TopicID            string    `json:"topic_id"`
+	OriginPriceStore   float64   `json:"original_price_store"`
+	OriginPriceService float64   `json:"original_price_service"`
+	CoverImage         string    `json:"cover_image"`
+	ImageKeys          []string  `json:"image_keys"`
+	VideoUrl           string    `json:"video_url"`
+	QRCode             string    `json:"qrcode"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+type ProductDeletedV1 struct {
+	ID        string   `json:"id"`
+	FolderID  string   `json:"folder_id"`
+	ImageKeys []string `json:"image_keys"`
+}
+
+type FolderCreatedV1 struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	ParentID    *string  `json:"parent_id"`
+	AncestorIDs []string `json:"ancestor_ids"`
+}
+
+type FolderMovedV1 struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	PreviousParentID *string  `json:"previous_parent_id"`
+	ParentID         *string  `json:"parent_id"`
+	AncestorIDs      []string `json:"ancestor_ids"`
+}
+
+// FolderDeletedV1 describes a folder delete in any mode. ImageKeys lists
+// the images of products removed by a cascade.
+type FolderDeletedV1 struct {
+	ID              string   `json:"id"`
+	Mode            string   `json:"mode"`
+	TargetID        *string  `json:"target_id"`
+	DeletedFolders  int64    `json:"deleted_folders"`
+	DeletedProducts int64    `json:"deleted_products"`
+	MovedFolders    int64    `json:"moved_folders"`
+	MovedProducts   int64    `json:"moved_products"`
+	ImageKeys       []string `json:"image_keys"`
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type KafkaPublisher struct {
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher writes to brokers with the topic taken from each
+// message. Partitions are chosen by hashing the key and every write waits
+// for all in-sync replicas.
+func NewKafkaPublisher(brokers []string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(brokers...),
+			Balancer:     &kafka.Hash{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+	}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, messages ...Message) error {
+
+	records := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+
+		headers := make([]kafka.Header, 0, len(message.Headers))
+		for key, value := range message.Headers {
+			headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+		}
+
+		records = append(records, kafka.Message{
+			Topic:   message.Topic,
+			Key:     message.Key,
+			Value:   message.Value,
+			Headers: headers,
+		})
+	}
+
+	return p.writer.WriteMessages(ctx, records...)
+}
+
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
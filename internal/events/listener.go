@@ -0,0 +1,153 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"product-service/internal/folder"
+	"product-service/internal/product"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Listener turns product and folder changes into domain events. It is
+// called inside the write's transaction, so with an outbox publisher the
+// events are stored atomically with the change; an error aborts the write.
+type Listener struct {
+	emitter           *Emitter
+	productRepository product.ProductRepository
+}
+
+func NewListener(emitter *Emitter, productRepository product.ProductRepository) *Listener {
+	return &Listener{
+		emitter:           emitter,
+		productRepository: productRepository,
+	}
+}
+
+func (l *Listener) ProductChanged(ctx context.Context, change product.ProductChange) error {
+
+	p := change.Product
+
+	if p == nil {
+		var err error
+		p, err = l.productRepository.GetProduct(ctx, change.ID)
+		if err != nil {
+			return fmt.Errorf("loading product %s for %s event: %w", change.ID.Hex(), change.Type, err)
+		}
+	}
+
+	var eventType string
+	var data interface{}
+
+	switch change.Type {
+	case product.ChangeCreated:
+		eventType, data = ProductCreated, toProductV1(p)
+	case product.ChangeUpdated:
+		eventType, data = ProductUpdated, toProductV1(p)
+	case product.ChangeDeleted:
+		eventType = ProductDeleted
+		data = ProductDeletedV1{
+			ID:        p.ID.Hex(),
+			FolderID:  p.FolderID.Hex(),
+			ImageKeys: p.ImageKeys(),
+		}
+	default:
+		return nil
+	}
+
+	if err := l.emitter.Emit(ctx, l.emitter.topics.Product, eventType, 1, change.ID.Hex(), data); err != nil {
+		return fmt.Errorf("publishing %s for product %s: %w", eventType, change.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+func (l *Listener) FolderChanged(ctx context.Context, change folder.FolderChange) error {
+
+	var eventType string
+	var data interface{}
+
+	switch change.Type {
+	case folder.ChangeCreated:
+		eventType = FolderCreated
+		data = FolderCreatedV1{
+			ID:          change.ID.Hex(),
+			Name:        change.Folder.Name,
+			ParentID:    hexOrNil(change.Folder.ParentID),
+			AncestorIDs: ancestorIDs(change.Folder),
+		}
+	case folder.ChangeMoved:
+		eventType = FolderMoved
+		data = FolderMovedV1{
+			ID:               change.ID.Hex(),
+			Name:             change.Folder.Name,
+			PreviousParentID: hexOrNil(change.PreviousParentID),
+			ParentID:         hexOrNil(change.Folder.ParentID),
+			AncestorIDs:      ancestorIDs(change.Folder),
+		}
+	case folder.ChangeDeleted:
+		eventType = FolderDeleted
+		deleted := FolderDeletedV1{
+			ID:        change.ID.Hex(),
+			TargetID:  hexOrNil(change.TargetID),
+			ImageKeys: change.ImageKeys,
+		}
+		if change.Deleted != nil {
+			deleted.Mode = change.Deleted.Mode
+			deleted.DeletedFolders = change.Deleted.DeletedFolders
+			deleted.DeletedProducts = change.Deleted.DeletedProducts
+			deleted.MovedFolders = change.Deleted.MovedFolders
+			deleted.MovedProducts = change.Deleted.MovedProducts
+		}
+		if deleted.ImageKeys == nil {
+			deleted.ImageKeys = []string{}
+		}
+		data = deleted
+	default:
+		return nil
+	}
+
+	if err := l.emitter.Emit(ctx, l.emitter.topics.Folder, eventType, 1, change.ID.Hex(), data); err != nil {
+		return fmt.Errorf("publishing %s for folder %s: %w", eventType, change.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+func toProductV1(p *product.Product) ProductV1 {
+	return ProductV1{
+		ID:                 p.ID.Hex(),
+		ProductName:        p.ProductName,
+		ProductDescription: p.ProductDescription,
+		FolderID:           p.FolderID.Hex(),
+		TopicID:            p.TopicID.Hex(),
+		OriginPriceStore:   p.OriginPriceStore,
+		OriginPriceService: p.OriginPriceService,
+		CoverImage:         p.CoverImage,
+		ImageKeys:          p.ImageKeys(),
+		VideoUrl:           p.VideoUrl,
+		QRCode:             p.QRCode,
+		CreatedAt:          p.CreatedAt,
+		UpdatedAt:          p.UpdatedAt,
+	}
+}
+
+func ancestorIDs(f *folder.Folder) []string {
+
+	ids := make([]string, 0, len(f.Ancestors))
+	for _, ancestor := range f.Ancestors {
+		ids = append(ids, ancestor.ID.Hex())
+	}
+
+	return ids
+}
+
+func hexOrNil(id *primitive.ObjectID) *string {
+
+	if id == nil {
+		return nil
+	}
+
+	hex := id.Hex()
+	return &hex
+}
@@ -0,0 +1,500 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"product-service/internal/folder"
+	"product-service/internal/product"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var testTopics = Topics{Product: "products.v1", Folder: "folders.v1"}
+
+// fakeProductRepository serves the product the listener loads when a
+// change carries none. Other methods panic through the nil interface.
+type fakeProductRepository struct {
+	product.ProductRepository
+	products map[primitive.ObjectID]*product.Product
+}
+
+func (r *fakeProductRepository) GetProduct(ctx context.Context, id primitive.ObjectID) (*product.Product, error) {
+
+	p, ok := r.products[id]
+	if !ok {
+		return nil, fmt.Errorf("product %s not found", id.Hex())
+	}
+
+	return p, nil
+}
+
+func newTestListener(products ...*product.Product) (*Listener, *MemoryPublisher) {
+
+	repository := &fakeProductRepository{products: make(map[primitive.ObjectID]*product.Product)}
+	for _, p := range products {
+		repository.products[p.ID] = p
+	}
+
+	publisher := NewMemoryPublisher()
+
+	return NewListener(NewEmitter(publisher, testTopics), repository), publisher
+}
+
+func testProduct() *product.Product {
+
+	price := 12.5
+	now := time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)
+
+	return &product.Product{
+		ID:                 primitive.NewObjectID(),
+		ProductName:        "Desk lamp",
+		ProductDescription: "Warm light",
+		FolderID:           primitive.NewObjectID(),
+		TopicID:            primitive.NewObjectID(),
+		OriginPriceStore:   20,
+		OriginPriceService: 25,
+		CoverImage:         "cover.png",
+		Images:             []product.ProductImage{{Key: "side.png"}},
+		Variations: []product.Variation{{
+			ID:            primitive.NewObjectID(),
+			VariationName: "Colour",
+			Options:       []product.VariationOption{{ID: primitive.NewObjectID(), Option: "Red", Price: &price, Image: "red.png"}},
+		}},
+		QRCode:    "https://example.com/p/1",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func testFolder(parentID *primitive.ObjectID) *folder.Folder {
+
+	f := &folder.Folder{ID: primitive.NewObjectID(), Name: "Lighting", ParentID: parentID}
+	if parentID != nil {
+		f.Ancestors = []folder.FolderAncestor{{ID: *parentID, Name: "Home"}}
+	}
+
+	return f
+}
+
+// checkMessage asserts the routing, headers and envelope of message and
+// validates its data against the published schema. It returns the data.
+func checkMessage(t *testing.T, message Message, topic, eventType, aggregateID string) map[string]interface{} {
+	t.Helper()
+
+	if message.Topic != topic {
+		t.Errorf("topic = %q, want %q", message.Topic, topic)
+	}
+	if string(message.Key) != aggregateID {
+		t.Errorf("key = %q, want the aggregate ID %q", message.Key, aggregateID)
+	}
+
+	wantHeaders := map[string]string{
+		"content-type":  "application/json",
+		"event-type":    eventType,
+		"event-version": "1",
+		"event-schema":  SchemaID(eventType, 1),
+	}
+	for name, want := range wantHeaders {
+		if got := message.Headers[name]; got != want {
+			t.Errorf("header %s = %q, want %q", name, got, want)
+		}
+	}
+
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(message.Value, &envelope); err != nil {
+		t.Fatalf("decoding envelope: %v", err)
+	}
+	validate(t, loadSchema(t, "schemas/envelope.v1.json"), envelope, "envelope")
+
+	var event Event
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		t.Fatalf("decoding envelope: %v", err)
+	}
+	if event.Type != eventType || event.Version != 1 || event.Source != source || event.AggregateID != aggregateID {
+		t.Errorf("envelope = %s/v%d from %s for %s, want %s/v1 from %s for %s",
+			event.Type, event.Version, event.Source, event.AggregateID, eventType, source, aggregateID)
+	}
+	if event.ID == "" || event.OccurredAt.IsZero() {
+		t.Errorf("envelope id %q, occurred_at %v, want both set", event.ID, event.OccurredAt)
+	}
+
+	schema, err := Schema(eventType, 1)
+	if err != nil {
+		t.Fatalf("no schema for %s v1: %v", eventType, err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(schema, &document); err != nil {
+		t.Fatal(err)
+	}
+	if document["$id"] != message.Headers["event-schema"] {
+		t.Errorf("schema $id = %v, want the event-schema header %q", document["$id"], message.Headers["event-schema"])
+	}
+
+	var data map[string]interface{}
+	if err := json.Unmarshal(event.Data, &data); err != nil {
+		t.Fatalf("decoding data: %v", err)
+	}
+	validate(t, document, data, "data")
+
+	return data
+}
+
+func loadSchema(t *testing.T, name string) map[string]interface{} {
+	t.Helper()
+
+	raw, err := schemas.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var document map[string]interface{}
+	if err := json.Unmarshal(raw, &document); err != nil {
+		t.Fatal(err)
+	}
+
+	return document
+}
+
+// validate checks value against the subset of JSON Schema the published
+// schemas use: type, required, properties, items, pattern, enum, const and
+// minimum.
+func validate(t *testing.T, schema map[string]interface{}, value interface{}, path string) {
+	t.Helper()
+
+	if types, ok := schema["type"]; ok && !matchesType(types, value) {
+		t.Errorf("%s = %v, want type %v", path, value, types)
+		return
+	}
+
+	if want, ok := schema["const"]; ok && value != want {
+		t.Errorf("%s = %v, want %v", path, value, want)
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, candidate := range enum {
+			if candidate == value {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s = %v, want one of %v", path, value, enum)
+		}
+	}
+
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if n, ok := value.(float64); ok && n < minimum {
+			t.Errorf("%s = %v, want at least %v", path, n, minimum)
+		}
+	}
+
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, ok := value.(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			t.Errorf("%s = %q, want to match %s", path, s, pattern)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				t.Errorf("%s is missing required %s", path, name)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name, property := range properties {
+			if field, ok := v[name]; ok {
+				validate(t, property.(map[string]interface{}), field, path+"."+name)
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validate(t, items, item, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+	}
+}
+
+func matchesType(types interface{}, value interface{}) bool {
+
+	names, ok := types.([]interface{})
+	if !ok {
+		names = []interface{}{types}
+	}
+
+	for _, name := range names {
+		switch name {
+		case "null":
+			if value == nil {
+				return true
+			}
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "number":
+			if _, ok := value.(float64); ok {
+				return true
+			}
+		case "integer":
+			if n, ok := value.(float64); ok && n == float64(int64(n)) {
+				return true
+			}
+		case "array":
+			if _, ok := value.([]interface{}); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]interface{}); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func TestListenerProductEvents(t *testing.T) {
+
+	p := testProduct()
+
+	tests := []struct {
+		name      string
+		change    product.ProductChange
+		eventType string
+	}{
+		{name: "created", change: product.ProductChange{ID: p.ID, Type: product.ChangeCreated, Product: p}, eventType: ProductCreated},
+		{name: "updated", change: product.ProductChange{ID: p.ID, Type: product.ChangeUpdated, Product: p}, eventType: ProductUpdated},
+		{name: "updated without product", change: product.ProductChange{ID: p.ID, Type: product.ChangeUpdated}, eventType: ProductUpdated},
+		{name: "deleted", change: product.ProductChange{ID: p.ID, Type: product.ChangeDeleted, Product: p}, eventType: ProductDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			listener, publisher := newTestListener(p)
+
+			if err := listener.ProductChanged(context.Background(), tt.change); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := publisher.Messages("")
+			if len(messages) != 1 {
+				t.Fatalf("published %d messages, want 1", len(messages))
+			}
+
+			data := checkMessage(t, messages[0], testTopics.Product, tt.eventType, p.ID.Hex())
+
+			if data["id"] != p.ID.Hex() || data["folder_id"] != p.FolderID.Hex() {
+				t.Errorf("data id/folder_id = %v/%v, want %s/%s", data["id"], data["folder_id"], p.ID.Hex(), p.FolderID.Hex())
+			}
+
+			keys, _ := json.Marshal(data["image_keys"])
+			if string(keys) != `["cover.png","red.png","side.png"]` {
+				t.Errorf("image_keys = %s, want every referenced image", keys)
+			}
+
+			if tt.eventType != ProductDeleted && data["product_name"] != p.ProductName {
+				t.Errorf("product_name = %v, want %q", data["product_name"], p.ProductName)
+			}
+		})
+	}
+}
+
+func TestListenerFolderEvents(t *testing.T) {
+
+	parentID := primitive.NewObjectID()
+	previousParentID := primitive.NewObjectID()
+	targetID := primitive.NewObjectID()
+	root := testFolder(nil)
+	child := testFolder(&parentID)
+
+	tests := []struct {
+		name      string
+		change    folder.FolderChange
+		eventType string
+		check     func(t *testing.T, data map[string]interface{})
+	}{
+		{
+			name:      "created at the root",
+			change:    folder.FolderChange{ID: root.ID, Type: folder.ChangeCreated, Folder: root},
+			eventType: FolderCreated,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["parent_id"] != nil || len(data["ancestor_ids"].([]interface{})) != 0 {
+					t.Errorf("parent_id/ancestor_ids = %v/%v, want null/[]", data["parent_id"], data["ancestor_ids"])
+				}
+			},
+		},
+		{
+			name:      "created in a folder",
+			change:    folder.FolderChange{ID: child.ID, Type: folder.ChangeCreated, Folder: child},
+			eventType: FolderCreated,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["parent_id"] != parentID.Hex() || data["name"] != child.Name {
+					t.Errorf("parent_id/name = %v/%v, want %s/%s", data["parent_id"], data["name"], parentID.Hex(), child.Name)
+				}
+			},
+		},
+		{
+			name:      "moved",
+			change:    folder.FolderChange{ID: child.ID, Type: folder.ChangeMoved, Folder: child, PreviousParentID: &previousParentID},
+			eventType: FolderMoved,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["previous_parent_id"] != previousParentID.Hex() || data["parent_id"] != parentID.Hex() {
+					t.Errorf("previous_parent_id/parent_id = %v/%v, want %s/%s", data["previous_parent_id"], data["parent_id"], previousParentID.Hex(), parentID.Hex())
+				}
+				ancestors := data["ancestor_ids"].([]interface{})
+				if len(ancestors) != 1 || ancestors[0] != parentID.Hex() {
+					t.Errorf("ancestor_ids = %v, want [%s]", ancestors, parentID.Hex())
+				}
+			},
+		},
+		{
+			name:      "moved to the root",
+			change:    folder.FolderChange{ID: root.ID, Type: folder.ChangeMoved, Folder: root, PreviousParentID: &previousParentID},
+			eventType: FolderMoved,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["parent_id"] != nil {
+					t.Errorf("parent_id = %v, want null", data["parent_id"])
+				}
+			},
+		},
+		{
+			name: "cascade delete",
+			change: folder.FolderChange{
+				ID:        child.ID,
+				Type:      folder.ChangeDeleted,
+				Deleted:   &folder.DeleteFolderResult{Mode: folder.DeleteModeCascade, DeletedFolders: 3, DeletedProducts: 5},
+				ImageKeys: []string{"a.png"},
+			},
+			eventType: FolderDeleted,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["mode"] != folder.DeleteModeCascade || data["deleted_folders"] != float64(3) || data["deleted_products"] != float64(5) {
+					t.Errorf("data = %v, want a cascade of 3 folders and 5 products", data)
+				}
+				if data["target_id"] != nil {
+					t.Errorf("target_id = %v, want null", data["target_id"])
+				}
+			},
+		},
+		{
+			name: "reparent delete",
+			change: folder.FolderChange{
+				ID:       child.ID,
+				Type:     folder.ChangeDeleted,
+				Deleted:  &folder.DeleteFolderResult{Mode: folder.DeleteModeReparent, DeletedFolders: 1, MovedFolders: 2, MovedProducts: 4},
+				TargetID: &targetID,
+			},
+			eventType: FolderDeleted,
+			check: func(t *testing.T, data map[string]interface{}) {
+				if data["target_id"] != targetID.Hex() || data["moved_folders"] != float64(2) || data["moved_products"] != float64(4) {
+					t.Errorf("data = %v, want a reparent of 2 folders and 4 products to %s", data, targetID.Hex())
+				}
+				if keys := data["image_keys"].([]interface{}); len(keys) != 0 {
+					t.Errorf("image_keys = %v, want []", keys)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			listener, publisher := newTestListener()
+
+			if err := listener.FolderChanged(context.Background(), tt.change); err != nil {
+				t.Fatal(err)
+			}
+
+			messages := publisher.Messages(testTopics.Folder)
+			if len(messages) != 1 {
+				t.Fatalf("published %d messages to %s, want 1", len(messages), testTopics.Folder)
+			}
+
+			data := checkMessage(t, messages[0], testTopics.Folder, tt.eventType, tt.change.ID.Hex())
+			if data["id"] != tt.change.ID.Hex() {
+				t.Errorf("data id = %v, want %s", data["id"], tt.change.ID.Hex())
+			}
+			tt.check(t, data)
+		})
+	}
+}
+
+func TestListenerIgnoresUnpublishedChanges(t *testing.T) {
+
+	listener, publisher := newTestListener()
+	f := testFolder(nil)
+
+	if err := listener.FolderChanged(context.Background(), folder.FolderChange{ID: f.ID, Type: folder.ChangeRenamed, Folder: f}); err != nil {
+		t.Fatal(err)
+	}
+
+	if messages := publisher.Messages(""); len(messages) != 0 {
+		t.Errorf("published %d messages for a rename, want 0", len(messages))
+	}
+}
+
+func TestListenerPublishFailure(t *testing.T) {
+
+	p := testProduct()
+	listener, publisher := newTestListener(p)
+
+	failure := errors.New("broker down")
+	publisher.FailWith(failure)
+
+	err := listener.ProductChanged(context.Background(), product.ProductChange{ID: p.ID, Type: product.ChangeCreated, Product: p})
+	if !errors.Is(err, failure) {
+		t.Errorf("ProductChanged error = %v, want the publish error so the write aborts", err)
+	}
+
+	err = listener.FolderChanged(context.Background(), folder.FolderChange{ID: primitive.NewObjectID(), Type: folder.ChangeCreated, Folder: testFolder(nil)})
+	if !errors.Is(err, failure) {
+		t.Errorf("FolderChanged error = %v, want the publish error so the write aborts", err)
+	}
+}
+
+func TestListenerMissingProduct(t *testing.T) {
+
+	listener, publisher := newTestListener()
+
+	err := listener.ProductChanged(context.Background(), product.ProductChange{ID: primitive.NewObjectID(), Type: product.ChangeUpdated})
+	if err == nil {
+		t.Error("ProductChanged succeeded for a product that cannot be loaded")
+	}
+	if messages := publisher.Messages(""); len(messages) != 0 {
+		t.Errorf("published %d messages, want 0", len(messages))
+	}
+}
+
+func TestSchemasExist(t *testing.T) {
+
+	for _, eventType := range []string{ProductCreated, ProductUpdated, ProductDeleted, FolderCreated, FolderMoved, FolderDeleted} {
+		schema, err := Schema(eventType, 1)
+		if err != nil {
+			t.Errorf("no schema for %s v1: %v", eventType, err)
+			continue
+		}
+
+		var document map[string]interface{}
+		if err := json.Unmarshal(schema, &document); err != nil {
+			t.Errorf("schema for %s v1 is not JSON: %v", eventType, err)
+			continue
+		}
+		if document["$id"] != SchemaID(eventType, 1) {
+			t.Errorf("schema for %s v1 has $id %v, want %s", eventType, document["$id"], SchemaID(eventType, 1))
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryPublisher keeps published messages in memory, standing in for the
+// broker in tests and local runs.
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, messages ...Message) error {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+
+	p.messages = append(p.messages, messages...)
+
+	return nil
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
+
+// Messages returns what was published to topic, or everything when topic
+// is empty, in publish order.
+func (p *MemoryPublisher) Messages(topic string) []Message {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var result []Message
+	for _, message := range p.messages {
+		if topic == "" || message.Topic == topic {
+			result = append(result, message)
+		}
+	}
+
+	return result
+}
+
+// FailWith makes every following Publish return err; nil restores it.
+func (p *MemoryPublisher) FailWith(err error) {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+func (p *MemoryPublisher) Reset() {
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = nil
+	p.err = nil
+}
@@ -0,0 +1,19 @@
+package events
+
+import "context"
+
+// Message is one record for the broker. Key selects the partition, so all
+// events of an aggregate stay in order.
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Publisher delivers messages to a broker. Publish returns once every
+// message has been accepted or fails as a whole.
+type Publisher interface {
+	Publish(ctx context.Context, messages ...Message) error
+	Close() error
+}
@@ -0,0 +1,24 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+)
+
+// Schemas are JSON Schema documents published as the contract with
+// consumers: envelope.v1.json for the envelope and <Type>.v<Version>.json
+// for each payload. A breaking payload change gets a new version and file;
+// the old one stays until consumers have migrated.
+//
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Schema returns the JSON Schema of the data of eventType at version.
+func Schema(eventType string, version int) ([]byte, error) {
+	return schemas.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+}
+
+// SchemaID is the $id of the schema of eventType at version.
+func SchemaID(eventType string, version int) string {
+	return fmt.Sprintf("urn:product-service:events:%s:v%d", eventType, version)
+}
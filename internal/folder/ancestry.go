@@ -0,0 +1,55 @@
+package folder
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ancestorsOf returns the ancestor path of a folder placed under parentID.
+func (s *folderService) ancestorsOf(ctx context.Context, parentID *primitive.ObjectID) ([]FolderAncestor, error) {
+
+	if parentID == nil {
+		return []FolderAncestor{}, nil
+	}
+
+	parent, err := s.folderReposity.GetFolder(ctx, *parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return parent.Breadcrumb(), nil
+}
+
+// rebuildAncestors derives every folder's ancestor path from the parent
+// links alone. Walking stops at a missing parent or at a folder already on
+// the path, so orphans and cycles yield a truncated path instead of looping.
+func rebuildAncestors(folders []*Folder) map[primitive.ObjectID][]FolderAncestor {
+
+	byID := make(map[primitive.ObjectID]*Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+
+	result := make(map[primitive.ObjectID][]FolderAncestor, len(folders))
+
+	for _, f := range folders {
+
+		path := []FolderAncestor{}
+		visited := map[primitive.ObjectID]bool{f.ID: true}
+
+		for current := f; current.ParentID != nil; {
+			parent, ok := byID[*current.ParentID]
+			if !ok || visited[parent.ID] {
+				break
+			}
+			visited[parent.ID] = true
+			path = append([]FolderAncestor{{ID: parent.ID, Name: parent.Name}}, path...)
+			current = parent
+		}
+
+		result[f.ID] = path
+	}
+
+	return result
+}
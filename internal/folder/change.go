@@ -0,0 +1,49 @@
+package folder
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ChangeCreated = "created"
+	ChangeRenamed = "renamed"
+	ChangeMoved   = "moved"
+	ChangeDeleted = "deleted"
+)
+
+type FolderChange struct {
+	ID   primitive.ObjectID
+	Type string
+	// Folder is the stored state after a create, rename or move. A move
+	// that also renames is reported as a move.
+	Folder *Folder
+	// PreviousParentID is the parent before a move; nil was the root.
+	PreviousParentID *primitive.ObjectID
+	// Deleted, TargetID and ImageKeys describe a delete: what was removed
+	// or moved, where reparented content went and the product images that
+	// were released.
+	Deleted   *DeleteFolderResult
+	TargetID  *primitive.ObjectID
+	ImageKeys []string
+}
+
+// ChangeListener is told about folder writes made through the folder
+// service, inside the write's transaction when the server runs one: an
+// error aborts the write.
+// Implementations must not block the request.
+type ChangeListener interface {
+	FolderChanged(ctx context.Context, change FolderChange) error
+}
+
+func (s *folderService) notify(ctx context.Context, change FolderChange) error {
+
+	for _, listener := range s.listeners {
+		if err := listener.FolderChanged(ctx, change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package folder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DeleteModeBlock    = "block"
+	DeleteModeCascade  = "cascade"
+	DeleteModeReparent = "reparent"
+)
+
+var (
+	ErrFolderNotEmpty  = errors.New("folder has child folders or products")
+	ErrInvalidTarget   = errors.New("target folder must exist and must not be the deleted folder or one of its descendants")
+	ErrTargetRequired  = errors.New("target is required in reparent mode")
+	ErrUnsupportedMode = errors.New("mode must be one of block, cascade or reparent")
+)
+
+// ProductStore is the part of the product repository that folder deletion
+// needs. Every method must honour the transaction carried by ctx.
+type ProductStore interface {
+	ProductCounter
+	ProductImageKeysByFolders(ctx context.Context, folderIDs []primitive.ObjectID) ([]string, error)
+	DeleteProductsByFolders(ctx context.Context, folderIDs []primitive.ObjectID) (int64, error)
+	MoveProductsToFolder(ctx context.Context, folderIDs []primitive.ObjectID, targetID primitive.ObjectID) (int64, error)
+}
+
+// deleteFolder runs inside a transaction and applies mode to folderID. It
+// returns the image keys of deleted products so they can be released once
+// the transaction has committed.
+func (s *folderService) deleteFolder(ctx context.Context, folderID primitive.ObjectID, mode string, targetID *primitive.ObjectID) (*DeleteFolderResult, []string, error) {
+
+	result := &DeleteFolderResult{Mode: mode}
+
+	if _, err := s.folderReposity.GetFolder(ctx, folderID); err != nil {
+		return nil, nil, err
+	}
+
+	switch mode {
+	case DeleteModeBlock:
+		children, err := s.folderReposity.CountChildren(ctx, folderID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		counts, err := s.productStore.CountProductsByFolder(ctx, []primitive.ObjectID{folderID})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if children > 0 || counts[folderID] > 0 {
+			return nil, nil, fmt.Errorf("%w: %d folders, %d products", ErrFolderNotEmpty, children, counts[folderID])
+		}
+
+		if err := s.folderReposity.DeleteFolders(ctx, []primitive.ObjectID{folderID}); err != nil {
+			return nil, nil, err
+		}
+
+		result.DeletedFolders = 1
+
+		return result, nil, nil
+
+	case DeleteModeCascade:
+		folders, err := s.folderReposity.GetAllFolders(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		subtree := newFolderIndex(folders).subtree(folderID)
+
+		keys, err := s.productStore.ProductImageKeysByFolders(ctx, subtree)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		deleted, err := s.productStore.DeleteProductsByFolders(ctx, subtree)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := s.folderReposity.DeleteFolders(ctx, subtree); err != nil {
+			return nil, nil, err
+		}
+
+		result.DeletedFolders = int64(len(subtree))
+		result.DeletedProducts = deleted
+
+		return result, keys, nil
+
+	case DeleteModeReparent:
+		if targetID == nil {
+			return nil, nil, ErrTargetRequired
+		}
+
+		folders, err := s.folderReposity.GetAllFolders(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		index := newFolderIndex(folders)
+
+		if _, ok := index.byID[*targetID]; !ok {
+			return nil, nil, ErrInvalidTarget
+		}
+
+		for _, id := range index.subtree(folderID) {
+			if id == *targetID {
+				return nil, nil, ErrInvalidTarget
+			}
+		}
+
+		moved, err := s.folderReposity.MoveChildren(ctx, folderID, *targetID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = s.folderReposity.UpdateDescendantAncestors(ctx, folderID, index.byID[*targetID].Breadcrumb())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		movedProducts, err := s.productStore.MoveProductsToFolder(ctx, []primitive.ObjectID{folderID}, *targetID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := s.folderReposity.DeleteFolders(ctx, []primitive.ObjectID{folderID}); err != nil {
+			return nil, nil, err
+		}
+
+		result.DeletedFolders = 1
+		result.MovedFolders = moved
+		result.MovedProducts = movedProducts
+
+		return result, nil, nil
+	}
+
+	return nil, nil, ErrUnsupportedMode
+}
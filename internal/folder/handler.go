@@ -1,11 +1,14 @@
 package folder
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"product-service/helper"
+	"product-service/pkg/constants"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type FolderHandler struct {
@@ -29,7 +32,7 @@ func (h *FolderHandler) CreateFolder(ctx *gin.Context) {
 
 	res, err := h.folderService.CreateFolder(ctx, &req)
 	if err != nil {
-		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		sendFolderError(ctx, err)
 		return
 	}
 
@@ -84,7 +87,7 @@ func (h *FolderHandler) UpdateFolder(ctx *gin.Context) {
 
 	err := h.folderService.UpdateFolder(ctx, &req, id)
 	if err != nil {
-		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		sendFolderError(ctx, err)
 		return
 	}
 
@@ -100,12 +103,90 @@ func (h *FolderHandler) DeleteFolder(ctx *gin.Context) {
 		return
 	}
 
-	err := h.folderService.DeleteFolder(ctx, id)
+	var req DeleteFolderRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	res, err := h.folderService.DeleteFolder(c, &req, id)
+	if err != nil {
+		sendFolderError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Folder deleted successfully", res)
+
+}
+
+func (h *FolderHandler) GetFolderTree(ctx *gin.Context) {
+
+	var req GetFolderTreeRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.folderService.GetFolderTree(ctx, &req)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			helper.SendError(ctx, http.StatusNotFound, errors.New("folder not found"), nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Folder tree retrieved successfully", res)
+
+}
+
+func (h *FolderHandler) CheckIntegrity(ctx *gin.Context) {
+
+	res, err := h.folderService.CheckIntegrity(ctx)
 	if err != nil {
 		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
 
-	helper.SendSuccess(ctx, http.StatusOK, "Folder deleted successfully", nil)
+	helper.SendSuccess(ctx, http.StatusOK, "Folder integrity checked successfully", res)
+
+}
+
+func sendFolderError(ctx *gin.Context, err error) {
 
-}
\ No newline at end of file
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		helper.SendError(ctx, http.StatusNotFound, errors.New("folder not found"), nil)
+	case errors.Is(err, ErrParentNotFound):
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+	case errors.Is(err, ErrFolderCycle), errors.Is(err, ErrFolderNotEmpty):
+		helper.SendError(ctx, http.StatusConflict, err, nil)
+	case errors.Is(err, ErrInvalidTarget), errors.Is(err, ErrTargetRequired), errors.Is(err, ErrUnsupportedMode):
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+	default:
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+	}
+}
+
+func (h *FolderHandler) RebuildAncestors(ctx *gin.Context) {
+
+	res, err := h.folderService.RebuildAncestors(ctx)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Folder ancestors rebuilt successfully", res)
+
+}
@@ -0,0 +1,88 @@
+package folder
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// checkIntegrity finds folders whose parent no longer exists and groups of
+// folders whose parent links form a loop. Both are unreachable from the
+// folder tree.
+func checkIntegrity(folders []*Folder) *IntegrityReport {
+
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	byID := make(map[primitive.ObjectID]*Folder, len(folders))
+	for _, f := range folders {
+		byID[f.ID] = f
+	}
+
+	report := &IntegrityReport{
+		Cycles:  [][]FolderRef{},
+		Orphans: []FolderRef{},
+	}
+
+	for _, f := range folders {
+		if f.ParentID != nil && byID[*f.ParentID] == nil {
+			report.Orphans = append(report.Orphans, toFolderRef(f))
+		}
+	}
+
+	state := make(map[primitive.ObjectID]int, len(folders))
+
+	for _, start := range folders {
+
+		if state[start.ID] != unvisited {
+			continue
+		}
+
+		var path []*Folder
+		current := start
+
+		for current != nil && state[current.ID] == unvisited {
+			state[current.ID] = inProgress
+			path = append(path, current)
+
+			if current.ParentID == nil {
+				current = nil
+				continue
+			}
+			current = byID[*current.ParentID]
+		}
+
+		if current != nil && state[current.ID] == inProgress {
+			var cycle []FolderRef
+			for i := len(path) - 1; i >= 0; i-- {
+				cycle = append(cycle, toFolderRef(path[i]))
+				if path[i].ID == current.ID {
+					break
+				}
+			}
+			report.Cycles = append(report.Cycles, cycle)
+		}
+
+		for _, f := range path {
+			state[f.ID] = done
+		}
+	}
+
+	report.Healthy = len(report.Cycles) == 0 && len(report.Orphans) == 0
+
+	return report
+}
+
+func toFolderRef(f *Folder) FolderRef {
+
+	ref := FolderRef{
+		ID:   f.ID.Hex(),
+		Name: f.Name,
+	}
+
+	if f.ParentID != nil {
+		parentID := f.ParentID.Hex()
+		ref.ParentID = &parentID
+	}
+
+	return ref
+}
@@ -6,4 +6,21 @@ type Folder struct {
 	ID       primitive.ObjectID  `json:"id" bson:"_id"`
 	Name     string              `json:"name" bson:"name"`
 	ParentID *primitive.ObjectID `json:"parent_id" bson:"parent_id"`
+	// Ancestors lists the folders above this one from the root down to the
+	// parent. It is kept in sync on rename and move.
+	Ancestors []FolderAncestor `json:"ancestors" bson:"ancestors"`
+}
+
+type FolderAncestor struct {
+	ID   primitive.ObjectID `json:"id" bson:"_id"`
+	Name string             `json:"name" bson:"name"`
+}
+
+// Breadcrumb returns the path from the root down to and including f.
+func (f *Folder) Breadcrumb() []FolderAncestor {
+
+	breadcrumb := make([]FolderAncestor, 0, len(f.Ancestors)+1)
+	breadcrumb = append(breadcrumb, f.Ancestors...)
+
+	return append(breadcrumb, FolderAncestor{ID: f.ID, Name: f.Name})
 }
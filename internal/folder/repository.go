@@ -6,14 +6,23 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type FolderRepository interface {
+	EnsureIndexes(ctx context.Context) error
 	CreateFolder(ctx context.Context, folder *Folder) (string, error)
 	GetAllFolders(ctx context.Context) ([]*Folder, error)
 	GetFolder(ctx context.Context, id primitive.ObjectID) (*Folder, error)
+	GetFoldersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Folder, error)
 	UpdateFolder(ctx context.Context, folder *Folder) error
 	DeleteFolder(ctx context.Context, id primitive.ObjectID) error
+	DeleteFolders(ctx context.Context, ids []primitive.ObjectID) error
+	CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error)
+	MoveChildren(ctx context.Context, parentID primitive.ObjectID, targetID primitive.ObjectID) (int64, error)
+	GetDescendantIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error)
+	UpdateDescendantAncestors(ctx context.Context, id primitive.ObjectID, prefix []FolderAncestor) error
+	SetAncestors(ctx context.Context, ancestors map[primitive.ObjectID][]FolderAncestor) (int64, error)
 }
 
 type folderRepository struct {
@@ -26,6 +35,16 @@ func NewFolderRepository(collection *mongo.Collection) FolderRepository {
 	}
 }
 
+func (r *folderRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "parent_id", Value: 1}}},
+		{Keys: bson.D{{Key: "ancestors._id", Value: 1}}},
+	})
+
+	return err
+}
+
 func (r *folderRepository) CreateFolder(ctx context.Context, folder *Folder) (string, error) {
 	
 	result, err := r.collection.InsertOne(ctx, folder)
@@ -71,6 +90,25 @@ func (r *folderRepository) GetFolder(ctx context.Context, id primitive.ObjectID)
 
 }
 
+func (r *folderRepository) GetFoldersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Folder, error) {
+
+	var folders []*Folder
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &folders)
+	if err != nil {
+		return nil, err
+	}
+
+	return folders, nil
+}
+
 func (r *folderRepository) UpdateFolder(ctx context.Context, folder *Folder) error {
 	
 	filter := bson.M{"_id": folder.ID}
@@ -96,4 +134,113 @@ func (r *folderRepository) DeleteFolder(ctx context.Context, id primitive.Object
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+func (r *folderRepository) DeleteFolders(ctx context.Context, ids []primitive.ObjectID) error {
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+
+	_, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *folderRepository) CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error) {
+
+	filter := bson.M{"parent_id": id}
+
+	return r.collection.CountDocuments(ctx, filter)
+}
+
+func (r *folderRepository) MoveChildren(ctx context.Context, parentID primitive.ObjectID, targetID primitive.ObjectID) (int64, error) {
+
+	filter := bson.M{"parent_id": parentID}
+
+	update := bson.M{"$set": bson.M{"parent_id": targetID}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *folderRepository) GetDescendantIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+
+	var folders []*Folder
+
+	filter := bson.M{"ancestors._id": id}
+
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &folders)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(folders))
+	for _, f := range folders {
+		ids = append(ids, f.ID)
+	}
+
+	return ids, nil
+}
+
+// UpdateDescendantAncestors rewrites the ancestor path of every descendant
+// of id: everything up to and including id is replaced by prefix and deeper
+// entries are kept as they are. prefix goes in as a $literal so folder names
+// starting with "$" are not read as field paths.
+func (r *folderRepository) UpdateDescendantAncestors(ctx context.Context, id primitive.ObjectID, prefix []FolderAncestor) error {
+
+	filter := bson.M{"ancestors._id": id}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ancestors": bson.M{"$concatArrays": bson.A{
+				bson.M{"$literal": prefix},
+				bson.M{"$slice": bson.A{
+					"$ancestors",
+					bson.M{"$add": bson.A{bson.M{"$indexOfArray": bson.A{"$ancestors._id", id}}, 1}},
+					bson.M{"$max": bson.A{bson.M{"$size": "$ancestors"}, 1}},
+				}},
+			}},
+		}}},
+	}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *folderRepository) SetAncestors(ctx context.Context, ancestors map[primitive.ObjectID][]FolderAncestor) (int64, error) {
+
+	if len(ancestors) == 0 {
+		return 0, nil
+	}
+
+	models := make([]mongo.WriteModel, 0, len(ancestors))
+	for id, path := range ancestors {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id}).
+			SetUpdate(bson.M{"$set": bson.M{"ancestors": path}}))
+	}
+
+	result, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
@@ -8,4 +8,14 @@ type CreateFolderRequest struct {
 type UpdateFolderRequest struct {
 	Name     string  `json:"name"`
 	ParentID *string `json:"parent_id"`
-}	
\ No newline at end of file
+}
+
+type GetFolderTreeRequest struct {
+	Root  string `form:"root"`
+	Depth int    `form:"depth"`
+}
+
+type DeleteFolderRequest struct {
+	Mode   string `form:"mode"`
+	Target string `form:"target"`
+}
@@ -1 +1,57 @@
-package folder
\ No newline at end of file
+package folder
+
+type FolderNode struct {
+	ID                 string        `json:"id"`
+	Name               string        `json:"name"`
+	ParentID           *string       `json:"parent_id"`
+	DirectProductCount int64         `json:"direct_product_count"`
+	TotalProductCount  int64         `json:"total_product_count"`
+	Children           []*FolderNode `json:"children"`
+}
+
+type FolderRef struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+type IntegrityReport struct {
+	Healthy bool          `json:"healthy"`
+	Cycles  [][]FolderRef `json:"cycles"`
+	Orphans []FolderRef   `json:"orphans"`
+}
+
+type DeleteFolderResult struct {
+	Mode            string `json:"mode"`
+	DeletedFolders  int64  `json:"deleted_folders"`
+	DeletedProducts int64  `json:"deleted_products"`
+	MovedFolders    int64  `json:"moved_folders"`
+	MovedProducts   int64  `json:"moved_products"`
+}
+
+type FolderResponse struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	ParentID   *string          `json:"parent_id"`
+	Breadcrumb []FolderAncestor `json:"breadcrumb"`
+}
+
+type RebuildAncestorsResult struct {
+	Updated int64 `json:"updated"`
+}
+
+func toFolderResponse(f *Folder) *FolderResponse {
+
+	res := &FolderResponse{
+		ID:         f.ID.Hex(),
+		Name:       f.Name,
+		Breadcrumb: f.Breadcrumb(),
+	}
+
+	if f.ParentID != nil {
+		parentID := f.ParentID.Hex()
+		res.ParentID = &parentID
+	}
+
+	return res
+}
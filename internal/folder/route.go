@@ -1,14 +1,22 @@
 package folder
 
-import "github.com/gin-gonic/gin"
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
 
-func RegisterRoutes(r *gin.Engine, folderHandler *FolderHandler) {
-	folderGroup := r.Group("api/v1/folders")
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, folderHandler *FolderHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	folderGroup := r.Group("api/v1/folders", middleware.Secured(verifier))
 	{
-		folderGroup.GET("", folderHandler.GetAllFolders)
-		folderGroup.GET("/:id", folderHandler.GetFolder)
-		folderGroup.POST("", folderHandler.CreateFolder)
-		folderGroup.PUT("/:id", folderHandler.UpdateFolder)
-		folderGroup.DELETE("/:id", folderHandler.DeleteFolder)
+		folderGroup.GET("", authorizer.Require(auth.FolderRead), folderHandler.GetAllFolders)
+		folderGroup.GET("/tree", authorizer.Require(auth.FolderRead), folderHandler.GetFolderTree)
+		folderGroup.GET("/integrity", authorizer.Require(auth.FolderWrite), folderHandler.CheckIntegrity)
+		folderGroup.POST("/ancestors/rebuild", authorizer.Require(auth.FolderWrite), folderHandler.RebuildAncestors)
+		folderGroup.GET("/:id", authorizer.Require(auth.FolderRead), folderHandler.GetFolder)
+		folderGroup.POST("", authorizer.Require(auth.FolderWrite), folderHandler.CreateFolder)
+		folderGroup.PUT("/:id", authorizer.Require(auth.FolderWrite), folderHandler.UpdateFolder)
+		folderGroup.DELETE("/:id", authorizer.Require(auth.FolderDelete), folderHandler.DeleteFolder)
 	}
-}
\ No newline at end of file
+}
@@ -3,25 +3,45 @@ package folder
 import (
 	"context"
 	"errors"
+	"fmt"
+	"product-service/internal/media"
+	"product-service/pkg/mongotx"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type FolderService interface {
 	CreateFolder(ctx context.Context, req *CreateFolderRequest) (string, error)
-	GetAllFolders(ctx context.Context) ([]*Folder, error)
-	GetFolder(ctx context.Context, id string) (*Folder, error)
+	GetAllFolders(ctx context.Context) ([]*FolderResponse, error)
+	GetFolder(ctx context.Context, id string) (*FolderResponse, error)
 	UpdateFolder(ctx context.Context, req *UpdateFolderRequest, id string) error
-	DeleteFolder(ctx context.Context, id string) error
+	DeleteFolder(ctx context.Context, req *DeleteFolderRequest, id string) (*DeleteFolderResult, error)
+	GetFolderTree(ctx context.Context, req *GetFolderTreeRequest) ([]*FolderNode, error)
+	CheckIntegrity(ctx context.Context) (*IntegrityReport, error)
+	RebuildAncestors(ctx context.Context) (*RebuildAncestorsResult, error)
 }
 
+var (
+	ErrParentNotFound = errors.New("parent folder not found")
+	ErrFolderCycle    = errors.New("folder cannot be moved under itself or one of its descendants")
+)
+
 type folderService struct {
 	folderReposity FolderRepository
+	productStore   ProductStore
+	transactions   mongotx.Runner
+	images         media.ImageLifecycle
+	listeners      []ChangeListener
 }
 
-func NewFolderService(folderRepository FolderRepository) FolderService {
+func NewFolderService(folderRepository FolderRepository, productStore ProductStore, transactions mongotx.Runner, images media.ImageLifecycle, listeners ...ChangeListener) FolderService {
 	return &folderService{
 		folderReposity: folderRepository,
+		productStore:   productStore,
+		transactions:   transactions,
+		images:         images,
+		listeners:      listeners,
 	}
 }
 
@@ -44,21 +64,61 @@ func (s *folderService) CreateFolder(ctx context.Context, req *CreateFolderReque
 		parentID = nil
 	}
 
+	id := primitive.NewObjectID()
+
+	if parentID != nil {
+		if err := s.validateParent(ctx, id, *parentID); err != nil {
+			return "", err
+		}
+	}
+
+	ancestors, err := s.ancestorsOf(ctx, parentID)
+	if err != nil {
+		return "", err
+	}
+
 	folder := &Folder{
-		ID:       primitive.NewObjectID(),
-		Name:     req.Name,
-		ParentID: parentID,
+		ID:        id,
+		Name:      req.Name,
+		ParentID:  parentID,
+		Ancestors: ancestors,
 	}
 
-	return s.folderReposity.CreateFolder(ctx, folder)
+	var createdID string
+
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		createdID, err = s.folderReposity.CreateFolder(ctx, folder)
+		if err != nil {
+			return err
+		}
+
+		return s.notify(ctx, FolderChange{ID: folder.ID, Type: ChangeCreated, Folder: folder})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return createdID, nil
 
 }
 
-func (s *folderService) GetAllFolders(ctx context.Context) ([]*Folder, error) {
-	return s.folderReposity.GetAllFolders(ctx)
+func (s *folderService) GetAllFolders(ctx context.Context) ([]*FolderResponse, error) {
+
+	folders, err := s.folderReposity.GetAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]*FolderResponse, 0, len(folders))
+	for _, f := range folders {
+		res = append(res, toFolderResponse(f))
+	}
+
+	return res, nil
 }
 
-func (s *folderService) GetFolder(ctx context.Context, id string) (*Folder, error) {
+func (s *folderService) GetFolder(ctx context.Context, id string) (*FolderResponse, error) {
 
 	if id == "" {
 		return nil, errors.New("id is required")
@@ -69,7 +129,12 @@ func (s *folderService) GetFolder(ctx context.Context, id string) (*Folder, erro
 		return nil, err
 	}
 
-	return s.folderReposity.GetFolder(ctx, objectID)
+	folder, err := s.folderReposity.GetFolder(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toFolderResponse(folder), nil
 }
 
 func (s *folderService) UpdateFolder(ctx context.Context, req *UpdateFolderRequest, id string) error {
@@ -90,20 +155,62 @@ func (s *folderService) UpdateFolder(ctx context.Context, req *UpdateFolderReque
 		return err
 	}
 
+	renamed := req.Name != "" && req.Name != folder.Name
+	moved := false
+	previousParentID := folder.ParentID
+
 	if req.Name != "" {
 		folder.Name = req.Name
 	}
 
-	if req.ParentID != nil {
+	if req.ParentID != nil && *req.ParentID == "" {
+		moved = folder.ParentID != nil
+		folder.ParentID = nil
+	} else if req.ParentID != nil {
 		result, err := primitive.ObjectIDFromHex(*req.ParentID)
 		if err != nil {
 			return err
 		}
+
+		if err := s.validateParent(ctx, folder.ID, result); err != nil {
+			return err
+		}
+
 		parentID = &result
+		moved = folder.ParentID == nil || *folder.ParentID != result
 		folder.ParentID = parentID
 	}
 
-	err = s.folderReposity.UpdateFolder(ctx, folder)
+	if moved {
+		folder.Ancestors, err = s.ancestorsOf(ctx, folder.ParentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+
+		err := s.folderReposity.UpdateFolder(ctx, folder)
+		if err != nil {
+			return err
+		}
+
+		if renamed || moved {
+			err = s.folderReposity.UpdateDescendantAncestors(ctx, folder.ID, folder.Breadcrumb())
+			if err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case moved:
+			return s.notify(ctx, FolderChange{ID: folder.ID, Type: ChangeMoved, Folder: folder, PreviousParentID: previousParentID})
+		case renamed:
+			return s.notify(ctx, FolderChange{ID: folder.ID, Type: ChangeRenamed, Folder: folder})
+		}
+
+		return nil
+	})
 	if err != nil {
 		return err
 	}
@@ -111,17 +218,163 @@ func (s *folderService) UpdateFolder(ctx context.Context, req *UpdateFolderReque
 	return nil
 }
 
-func (s *folderService) DeleteFolder(ctx context.Context, id string) error {
+func (s *folderService) DeleteFolder(ctx context.Context, req *DeleteFolderRequest, id string) (*DeleteFolderResult, error) {
 
 	if id == "" {
-		return errors.New("id is required")
+		return nil, errors.New("id is required")
 	}
 
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	mode := req.Mode
+	if mode == "" {
+		mode = DeleteModeBlock
+	}
+
+	var targetID *primitive.ObjectID
+	if req.Target != "" {
+		target, err := primitive.ObjectIDFromHex(req.Target)
+		if err != nil {
+			return nil, err
+		}
+		targetID = &target
+	}
+
+	var result *DeleteFolderResult
+	var releasedKeys []string
+
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		result, releasedKeys, err = s.deleteFolder(ctx, objectID, mode, targetID)
+		if err != nil {
+			return err
+		}
+
+		return s.notify(ctx, FolderChange{
+			ID:        objectID,
+			Type:      ChangeDeleted,
+			Deleted:   result,
+			TargetID:  targetID,
+			ImageKeys: releasedKeys,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.images.Release(ctx, releasedKeys...)
+
+	return result, nil
+
+}
+
+func (s *folderService) GetFolderTree(ctx context.Context, req *GetFolderTreeRequest) ([]*FolderNode, error) {
+
+	if req.Depth < 0 {
+		return nil, fmt.Errorf("depth must not be negative: %d", req.Depth)
+	}
+
+	folders, err := s.folderReposity.GetAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := newFolderIndex(folders)
+	roots := index.roots
+
+	var scope []primitive.ObjectID
+
+	if req.Root != "" {
+		rootID, err := primitive.ObjectIDFromHex(req.Root)
+		if err != nil {
+			return nil, err
+		}
+
+		root, ok := index.byID[rootID]
+		if !ok {
+			return nil, mongo.ErrNoDocuments
+		}
+
+		roots = []*Folder{root}
+		scope = index.subtree(rootID)
+	}
+
+	counts, err := s.productStore.CountProductsByFolder(ctx, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make([]*FolderNode, 0, len(roots))
+	visited := make(map[primitive.ObjectID]bool, len(folders))
+
+	for _, root := range roots {
+		tree = append(tree, index.buildNode(root, counts, req.Depth, 0, visited))
+	}
+
+	return tree, nil
+
+}
+
+func (s *folderService) CheckIntegrity(ctx context.Context) (*IntegrityReport, error) {
+
+	folders, err := s.folderReposity.GetAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return checkIntegrity(folders), nil
+
+}
+
+// validateParent walks the ancestor chain of parentID and rejects the move
+// when it reaches folderID, which would turn the hierarchy into a cycle. A
+// chain that loops without reaching folderID is already corrupted and is
+// rejected as well rather than walked forever.
+func (s *folderService) validateParent(ctx context.Context, folderID primitive.ObjectID, parentID primitive.ObjectID) error {
+
+	visited := make(map[primitive.ObjectID]bool)
+	current := parentID
+
+	for {
+		if current == folderID || visited[current] {
+			return ErrFolderCycle
+		}
+		visited[current] = true
+
+		ancestor, err := s.folderReposity.GetFolder(ctx, current)
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				if current == parentID {
+					return ErrParentNotFound
+				}
+				return nil
+			}
+			return err
+		}
+
+		if ancestor.ParentID == nil {
+			return nil
+		}
+
+		current = *ancestor.ParentID
+	}
+}
+
+func (s *folderService) RebuildAncestors(ctx context.Context) (*RebuildAncestorsResult, error) {
+
+	folders, err := s.folderReposity.GetAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.folderReposity.SetAncestors(ctx, rebuildAncestors(folders))
+	if err != nil {
+		return nil, err
 	}
 
-	return s.folderReposity.DeleteFolder(ctx, objectID)
+	return &RebuildAncestorsResult{Updated: updated}, nil
 
 }
@@ -0,0 +1,116 @@
+package folder
+
+import (
+	"context"
+	"sort"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductCounter reports how many products each folder holds directly. It is
+// implemented by the product repository so the folder package does not
+// depend on the product package.
+type ProductCounter interface {
+	CountProductsByFolder(ctx context.Context, folderIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+}
+
+// folderIndex is an in-memory view of the folder hierarchy.
+type folderIndex struct {
+	byID     map[primitive.ObjectID]*Folder
+	children map[primitive.ObjectID][]*Folder
+	roots    []*Folder
+}
+
+// newFolderIndex groups folders by parent. Folders whose parent is missing
+// are treated as roots so they stay reachable.
+func newFolderIndex(folders []*Folder) *folderIndex {
+
+	index := &folderIndex{
+		byID:     make(map[primitive.ObjectID]*Folder, len(folders)),
+		children: make(map[primitive.ObjectID][]*Folder),
+	}
+
+	for _, f := range folders {
+		index.byID[f.ID] = f
+	}
+
+	for _, f := range folders {
+		if f.ParentID == nil || index.byID[*f.ParentID] == nil {
+			index.roots = append(index.roots, f)
+			continue
+		}
+		index.children[*f.ParentID] = append(index.children[*f.ParentID], f)
+	}
+
+	byName := func(list []*Folder) {
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+
+	byName(index.roots)
+	for _, list := range index.children {
+		byName(list)
+	}
+
+	return index
+}
+
+// subtree returns the ids of root and all its descendants. A visited set
+// guards against cycles in corrupted data.
+func (x *folderIndex) subtree(root primitive.ObjectID) []primitive.ObjectID {
+
+	var ids []primitive.ObjectID
+	visited := make(map[primitive.ObjectID]bool)
+
+	var walk func(id primitive.ObjectID)
+	walk = func(id primitive.ObjectID) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		ids = append(ids, id)
+		for _, child := range x.children[id] {
+			walk(child.ID)
+		}
+	}
+
+	walk(root)
+
+	return ids
+}
+
+// buildNode renders f and its descendants. Recursive counts always cover the
+// whole subtree; depth only limits how many levels of children are returned,
+// with 0 meaning unlimited.
+func (x *folderIndex) buildNode(f *Folder, counts map[primitive.ObjectID]int64, depth int, level int, visited map[primitive.ObjectID]bool) *FolderNode {
+
+	visited[f.ID] = true
+
+	node := &FolderNode{
+		ID:                 f.ID.Hex(),
+		Name:               f.Name,
+		DirectProductCount: counts[f.ID],
+		TotalProductCount:  counts[f.ID],
+		Children:           []*FolderNode{},
+	}
+
+	if f.ParentID != nil {
+		parentID := f.ParentID.Hex()
+		node.ParentID = &parentID
+	}
+
+	for _, child := range x.children[f.ID] {
+
+		if visited[child.ID] {
+			continue
+		}
+
+		childNode := x.buildNode(child, counts, depth, level+1, visited)
+		node.TotalProductCount += childNode.TotalProductCount
+
+		if depth == 0 || level < depth {
+			node.Children = append(node.Children, childNode)
+		}
+	}
+
+	return node
+}
@@ -0,0 +1,128 @@
+package importer
+
+import (
+	"context"
+	"product-service/internal/folder"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// folderResolver maps folder paths such as "Science > Lab equipment" to
+// folder ids. Missing folders are created when create is set; in a dry run
+// they are only recorded and given a placeholder id.
+type folderResolver struct {
+	folderService folder.FolderService
+	create        bool
+	dryRun        bool
+	paths         map[string]string
+	created       []string
+}
+
+func newFolderResolver(ctx context.Context, folderService folder.FolderService, create bool, dryRun bool) (*folderResolver, error) {
+
+	folders, err := folderService.GetAllFolders(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &folderResolver{
+		folderService: folderService,
+		create:        create,
+		dryRun:        dryRun,
+		paths:         make(map[string]string, len(folders)),
+		created:       []string{},
+	}
+
+	for _, f := range folders {
+		names := make([]string, 0, len(f.Breadcrumb))
+		for _, crumb := range f.Breadcrumb {
+			names = append(names, crumb.Name)
+		}
+		key := pathKey(names)
+		if _, ok := r.paths[key]; !ok {
+			r.paths[key] = f.ID
+		}
+	}
+
+	return r, nil
+}
+
+func (r *folderResolver) resolve(ctx context.Context, path string) (string, error) {
+
+	names := splitPath(path)
+	if len(names) == 0 {
+		return "", errEmptyFolderPath
+	}
+
+	if id, ok := r.paths[pathKey(names)]; ok {
+		return id, nil
+	}
+
+	if !r.create {
+		return "", errFolderNotFound(path)
+	}
+
+	var parentID *string
+
+	for i := range names {
+
+		key := pathKey(names[:i+1])
+
+		if id, ok := r.paths[key]; ok {
+			parent := id
+			parentID = &parent
+			continue
+		}
+
+		var id string
+
+		if r.dryRun {
+			id = primitive.NewObjectID().Hex()
+		} else {
+			var err error
+			id, err = r.folderService.CreateFolder(ctx, &folder.CreateFolderRequest{
+				Name:     names[i],
+				ParentID: parentID,
+			})
+			if err != nil {
+				return "", err
+			}
+		}
+
+		r.paths[key] = id
+		r.created = append(r.created, strings.Join(names[:i+1], " > "))
+
+		parent := id
+		parentID = &parent
+	}
+
+	return *parentID, nil
+}
+
+// splitPath accepts ">" or "/" between folder names.
+func splitPath(path string) []string {
+
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '>' || r == '/'
+	})
+
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if name := strings.TrimSpace(part); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func pathKey(names []string) string {
+
+	keys := make([]string, 0, len(names))
+	for _, name := range names {
+		keys = append(keys, strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	return strings.Join(keys, "\x00")
+}
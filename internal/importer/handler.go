@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"product-service/helper"
+	"product-service/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxImportFileSize = 20 << 20
+
+type ImportHandler struct {
+	importService ImportService
+}
+
+func NewImportHandler(importService ImportService) *ImportHandler {
+	return &ImportHandler{
+		importService: importService,
+	}
+}
+
+func (h *ImportHandler) StartImport(ctx *gin.Context) {
+
+	var req ImportRequest
+
+	if err := ctx.ShouldBind(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	fileHeader, err := ctx.FormFile("file")
+	if err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("file is required"), nil)
+		return
+	}
+
+	if fileHeader.Size > maxImportFileSize {
+		helper.SendError(ctx, http.StatusRequestEntityTooLarge, errors.New("file must not exceed 20 MB"), nil)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	job, err := h.importService.StartImport(ctx, &req, fileHeader.Filename, data, ctx.GetString(constants.UserID))
+	if err != nil {
+		if errors.Is(err, ErrInvalidImport) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if job.Status == StatusPending || job.Status == StatusRunning {
+		helper.SendSuccess(ctx, http.StatusAccepted, "Import started", job)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Import finished", job)
+
+}
+
+func (h *ImportHandler) GetJob(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	job, err := h.importService.GetJob(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			helper.SendError(ctx, http.StatusNotFound, errors.New("import job not found"), nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Import job retrieved successfully", job)
+
+}
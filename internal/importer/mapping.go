@@ -0,0 +1,157 @@
+package importer
+
+import (
+	"fmt"
+	"product-service/internal/product"
+	"strconv"
+	"strings"
+)
+
+const (
+	FieldProductName        = "product_name"
+	FieldOriginPriceStore   = "origin_price_store"
+	FieldOriginPriceService = "origin_price_service"
+	FieldProductDescription = "product_description"
+	FieldCoverImage         = "cover_image"
+	FieldTopicID            = "topic_id"
+	FieldFolderID           = "folder_id"
+	FieldFolder             = "folder"
+	FieldVideoUrl           = "video_url"
+)
+
+var importFields = []string{
+	FieldProductName,
+	FieldOriginPriceStore,
+	FieldOriginPriceService,
+	FieldProductDescription,
+	FieldCoverImage,
+	FieldTopicID,
+	FieldFolderID,
+	FieldFolder,
+	FieldVideoUrl,
+}
+
+// columnMap holds the column index of every mapped field.
+type columnMap map[string]int
+
+// buildColumnMap matches fields to header columns. mapping goes from field
+// to header name; unmapped fields fall back to a header with the field's
+// own name. Header matching ignores case and surrounding spaces.
+func buildColumnMap(header []string, mapping map[string]string) (columnMap, error) {
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := index[key]; !ok {
+			index[key] = i
+		}
+	}
+
+	known := make(map[string]bool, len(importFields))
+	for _, field := range importFields {
+		known[field] = true
+	}
+
+	columns := columnMap{}
+
+	for field, column := range mapping {
+		if !known[field] {
+			return nil, fmt.Errorf("unknown field in mapping: %s", field)
+		}
+		i, ok := index[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			return nil, fmt.Errorf("column %q mapped to %s is not in the header", column, field)
+		}
+		columns[field] = i
+	}
+
+	for _, field := range importFields {
+		if _, ok := columns[field]; ok {
+			continue
+		}
+		if i, ok := index[field]; ok {
+			columns[field] = i
+		}
+	}
+
+	if _, ok := columns[FieldProductName]; !ok {
+		return nil, fmt.Errorf("no column is mapped to %s", FieldProductName)
+	}
+
+	_, hasFolderID := columns[FieldFolderID]
+	_, hasFolder := columns[FieldFolder]
+	if !hasFolderID && !hasFolder {
+		return nil, fmt.Errorf("no column is mapped to %s or %s", FieldFolderID, FieldFolder)
+	}
+
+	return columns, nil
+}
+
+func (c columnMap) value(row []string, field string) string {
+
+	i, ok := c[field]
+	if !ok || i >= len(row) {
+		return ""
+	}
+
+	return strings.TrimSpace(row[i])
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// toRequest converts a row into a create request and the folder path it
+// names, if any. Folder ids are resolved by the caller.
+func (c columnMap) toRequest(row []string) (*product.CreateProductRequest, string, *RowError) {
+
+	req := &product.CreateProductRequest{
+		ProductName:        c.value(row, FieldProductName),
+		ProductDescription: c.value(row, FieldProductDescription),
+		CoverImage:         c.value(row, FieldCoverImage),
+		TopicID:            c.value(row, FieldTopicID),
+		FolderID:           c.value(row, FieldFolderID),
+		VideoUrl:           c.value(row, FieldVideoUrl),
+	}
+
+	var err error
+
+	req.OriginPriceStore, err = parsePrice(c.value(row, FieldOriginPriceStore))
+	if err != nil {
+		return nil, "", &RowError{Field: FieldOriginPriceStore, Message: err.Error()}
+	}
+
+	req.OriginPriceService, err = parsePrice(c.value(row, FieldOriginPriceService))
+	if err != nil {
+		return nil, "", &RowError{Field: FieldOriginPriceService, Message: err.Error()}
+	}
+
+	return req, c.value(row, FieldFolder), nil
+}
+
+// parsePrice accepts plain numbers and numbers with thousands separators
+// such as "120,000" or "120 000".
+func parsePrice(value string) (float64, error) {
+
+	if value == "" {
+		return 0, nil
+	}
+
+	cleaned := strings.NewReplacer(",", "", " ", "", " ", "").Replace(value)
+
+	price, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid price: %s", value)
+	}
+
+	if price < 0 {
+		return 0, fmt.Errorf("price must not be negative: %s", value)
+	}
+
+	return price, nil
+}
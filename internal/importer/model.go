@@ -0,0 +1,47 @@
+package importer
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+)
+
+type ImportJob struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	Status        string             `json:"status" bson:"status"`
+	FileName      string             `json:"file_name" bson:"file_name"`
+	Format        string             `json:"format" bson:"format"`
+	DryRun        bool               `json:"dry_run" bson:"dry_run"`
+	CreateFolders bool               `json:"create_folders" bson:"create_folders"`
+	TotalRows     int                `json:"total_rows" bson:"total_rows"`
+	ProcessedRows int                `json:"processed_rows" bson:"processed_rows"`
+	// CreatedCount counts created products, or in a dry run the valid rows.
+	CreatedCount int `json:"created_count" bson:"created_count"`
+	FailedCount  int `json:"failed_count" bson:"failed_count"`
+	// CreatedFolders lists the folder paths created, or in a dry run the
+	// paths that would be created.
+	CreatedFolders []string   `json:"created_folders" bson:"created_folders"`
+	Errors         []RowError `json:"errors" bson:"errors"`
+	// ErrorsTruncated is set when more rows failed than Errors keeps.
+	ErrorsTruncated bool       `json:"errors_truncated" bson:"errors_truncated"`
+	Message         string     `json:"message" bson:"message"`
+	CreatedBy       string     `json:"created_by" bson:"created_by"`
+	CreatedAt       time.Time  `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" bson:"updated_at"`
+	FinishedAt      *time.Time `json:"finished_at" bson:"finished_at"`
+}
+
+// RowError reports why a row was rejected. Row is the spreadsheet row
+// number, counting the header as row 1.
+type RowError struct {
+	Row     int    `json:"row" bson:"row"`
+	Field   string `json:"field,omitempty" bson:"field,omitempty"`
+	Message string `json:"message" bson:"message"`
+}
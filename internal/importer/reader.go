@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported import format, expected csv or xlsx")
+
+// detectFormat uses the explicit format when given and the file extension
+// otherwise.
+func detectFormat(format string, fileName string) (string, error) {
+
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(fileName)), ".")
+	}
+
+	switch strings.ToLower(format) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+
+	return "", ErrUnsupportedFormat
+}
+
+// readTable returns the header and the data rows of the first sheet. Fully
+// blank rows are kept so row numbers match the spreadsheet.
+func readTable(format string, data []byte) ([]string, [][]string, error) {
+
+	var table [][]string
+	var err error
+
+	switch format {
+	case FormatCSV:
+		table, err = readCSV(data)
+	case FormatXLSX:
+		table, err = readXLSX(data)
+	default:
+		return nil, nil, ErrUnsupportedFormat
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(table) == 0 {
+		return nil, nil, errors.New("file has no header row")
+	}
+
+	return table[0], table[1:], nil
+}
+
+func readCSV(data []byte) ([][]string, error) {
+
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var table [][]string
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv: %w", err)
+		}
+		table = append(table, record)
+	}
+
+	return table, nil
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+
+	file, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx: %w", err)
+	}
+	defer file.Close()
+
+	sheets := file.GetSheetList()
+	if len(sheets) == 0 {
+		return nil, errors.New("xlsx file has no sheets")
+	}
+
+	return file.GetRows(sheets[0])
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type JobRepository interface {
+	CreateJob(ctx context.Context, job *ImportJob) error
+	UpdateJob(ctx context.Context, job *ImportJob) error
+	GetJob(ctx context.Context, id primitive.ObjectID) (*ImportJob, error)
+}
+
+type jobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewJobRepository(collection *mongo.Collection) JobRepository {
+	return &jobRepository{
+		collection: collection,
+	}
+}
+
+func (r *jobRepository) CreateJob(ctx context.Context, job *ImportJob) error {
+
+	_, err := r.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *jobRepository) UpdateJob(ctx context.Context, job *ImportJob) error {
+
+	filter := bson.M{"_id": job.ID}
+
+	update := bson.M{"$set": job}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *jobRepository) GetJob(ctx context.Context, id primitive.ObjectID) (*ImportJob, error) {
+
+	var job ImportJob
+
+	filter := bson.M{"_id": id}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+
+}
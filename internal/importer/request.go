@@ -0,0 +1,9 @@
+package importer
+
+type ImportRequest struct {
+	Format string `form:"format"`
+	// Mapping is a JSON object from field name to column header.
+	Mapping       string `form:"mapping"`
+	DryRun        bool   `form:"dry_run"`
+	CreateFolders bool   `form:"create_folders"`
+}
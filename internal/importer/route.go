@@ -0,0 +1,16 @@
+package importer
+
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, importHandler *ImportHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	importGroup := r.Group("api/v1/products/import", middleware.Secured(verifier))
+	{
+		importGroup.POST("", authorizer.Require(auth.ProductWrite), importHandler.StartImport)
+		importGroup.GET("/jobs/:id", authorizer.Require(auth.ProductWrite), importHandler.GetJob)
+	}
+}
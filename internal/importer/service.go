@@ -0,0 +1,251 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"product-service/internal/folder"
+	"product-service/internal/product"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// syncRowLimit is the largest file imported within the request; larger
+	// files run in the background and are polled through GetJob.
+	syncRowLimit     = 100
+	maxImportRows    = 50000
+	maxReportedRows  = 1000
+	progressInterval = 50
+)
+
+var (
+	ErrInvalidImport   = errors.New("invalid import file")
+	errEmptyFolderPath = errors.New("folder is empty")
+)
+
+func errFolderNotFound(path string) error {
+	return fmt.Errorf("folder %q does not exist", path)
+}
+
+type ImportService interface {
+	StartImport(ctx context.Context, req *ImportRequest, fileName string, data []byte, userID string) (*ImportJob, error)
+	GetJob(ctx context.Context, id string) (*ImportJob, error)
+	Wait()
+}
+
+type importService struct {
+	jobRepository  JobRepository
+	productService product.ProductService
+	folderService  folder.FolderService
+	background     context.Context
+	jobs           sync.WaitGroup
+}
+
+// NewImportService runs background imports under background, which should
+// be cancelled when the server stops. Jobs still running at that point are
+// marked failed.
+func NewImportService(background context.Context, jobRepository JobRepository, productService product.ProductService, folderService folder.FolderService) ImportService {
+	return &importService{
+		jobRepository:  jobRepository,
+		productService: productService,
+		folderService:  folderService,
+		background:     background,
+	}
+}
+
+// StartImport parses the file and records a job for it. Small files are
+// processed before returning; larger ones continue in the background and the
+// returned job is still pending.
+func (s *importService) StartImport(ctx context.Context, req *ImportRequest, fileName string, data []byte, userID string) (*ImportJob, error) {
+
+	format, err := detectFormat(req.Format, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	mapping := map[string]string{}
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &mapping); err != nil {
+			return nil, fmt.Errorf("%w: mapping must be a JSON object: %v", ErrInvalidImport, err)
+		}
+	}
+
+	header, rows, err := readTable(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	if len(rows) > maxImportRows {
+		return nil, fmt.Errorf("%w: at most %d rows can be imported at once", ErrInvalidImport, maxImportRows)
+	}
+
+	columns, err := buildColumnMap(header, mapping)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	job := &ImportJob{
+		ID:             primitive.NewObjectID(),
+		Status:         StatusPending,
+		FileName:       fileName,
+		Format:         format,
+		DryRun:         req.DryRun,
+		CreateFolders:  req.CreateFolders,
+		TotalRows:      len(rows),
+		CreatedFolders: []string{},
+		Errors:         []RowError{},
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if err := s.jobRepository.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+
+	if len(rows) <= syncRowLimit {
+		s.run(ctx, job, columns, rows)
+		return job, nil
+	}
+
+	// The request context is not used past this point: gin reuses it once
+	// the handler returns.
+	s.jobs.Add(1)
+	go func() {
+		defer s.jobs.Done()
+		s.run(s.background, job, columns, rows)
+	}()
+
+	return job, nil
+
+}
+
+// Wait blocks until every background import has finished or, after the
+// background context was cancelled, recorded that it was interrupted.
+func (s *importService) Wait() {
+	s.jobs.Wait()
+}
+
+func (s *importService) GetJob(ctx context.Context, id string) (*ImportJob, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.jobRepository.GetJob(ctx, objectID)
+
+}
+
+func (s *importService) run(ctx context.Context, job *ImportJob, columns columnMap, rows [][]string) {
+
+	job.Status = StatusRunning
+	s.save(ctx, job)
+
+	folders, err := newFolderResolver(ctx, s.folderService, job.CreateFolders, job.DryRun)
+	if err != nil {
+		s.finish(ctx, job, nil, StatusFailed, err.Error())
+		return
+	}
+
+	for i, row := range rows {
+
+		if ctx.Err() != nil {
+			s.finish(ctx, job, folders, StatusFailed, "import interrupted by server shutdown")
+			return
+		}
+
+		rowNumber := i + 2
+
+		if !isBlank(row) {
+			if rowErr := s.importRow(ctx, job, columns, folders, row); rowErr != nil {
+				rowErr.Row = rowNumber
+				job.FailedCount++
+				if len(job.Errors) < maxReportedRows {
+					job.Errors = append(job.Errors, *rowErr)
+				} else {
+					job.ErrorsTruncated = true
+				}
+			}
+		}
+
+		job.ProcessedRows++
+
+		if job.ProcessedRows%progressInterval == 0 {
+			job.CreatedFolders = folders.created
+			s.save(ctx, job)
+		}
+	}
+
+	s.finish(ctx, job, folders, StatusCompleted, "")
+}
+
+// importRow validates a row with the same rules as CreateProduct and, unless
+// the job is a dry run, creates the product.
+func (s *importService) importRow(ctx context.Context, job *ImportJob, columns columnMap, folders *folderResolver, row []string) *RowError {
+
+	req, folderPath, rowErr := columns.toRequest(row)
+	if rowErr != nil {
+		return rowErr
+	}
+
+	if req.FolderID == "" && folderPath != "" {
+		id, err := folders.resolve(ctx, folderPath)
+		if err != nil {
+			return &RowError{Field: FieldFolder, Message: err.Error()}
+		}
+		req.FolderID = id
+	}
+
+	if err := product.ValidateCreateProductRequest(req); err != nil {
+		return &RowError{Message: err.Error()}
+	}
+
+	if job.DryRun {
+		job.CreatedCount++
+		return nil
+	}
+
+	if _, err := s.productService.CreateProduct(ctx, req); err != nil {
+		return &RowError{Message: err.Error()}
+	}
+
+	job.CreatedCount++
+
+	return nil
+}
+
+func (s *importService) finish(ctx context.Context, job *ImportJob, folders *folderResolver, status string, message string) {
+
+	now := time.Now()
+
+	if folders != nil {
+		job.CreatedFolders = folders.created
+	}
+
+	if job.CreatedFolders == nil {
+		job.CreatedFolders = []string{}
+	}
+
+	job.Status = status
+	job.Message = message
+	job.FinishedAt = &now
+
+	// The final state is saved even when ctx was cancelled, so an
+	// interrupted job does not stay running.
+	s.save(context.WithoutCancel(ctx), job)
+}
+
+func (s *importService) save(ctx context.Context, job *ImportJob) {
+
+	job.UpdatedAt = time.Now()
+
+	if err := s.jobRepository.UpdateJob(ctx, job); err != nil {
+		log.Println("Error saving import job:", err)
+	}
+}
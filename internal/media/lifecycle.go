@@ -0,0 +1,77 @@
+package media
+
+import (
+	"context"
+	"log"
+	"product-service/pkg/constants"
+	"product-service/pkg/uploader"
+	"time"
+)
+
+// ImageLifecycle removes image keys that are no longer referenced. Keys that
+// cannot be deleted right away are queued and retried by the DeletionWorker,
+// so a media store outage never leaks storage.
+type ImageLifecycle interface {
+	Release(ctx context.Context, keys ...string)
+}
+
+// ImageReferences tells whether an image key is still in use. Keys can be
+// shared, for example by imported products with the same cover, so a key
+// released by one product may still belong to another.
+type ImageReferences interface {
+	ImageKeyReferenced(ctx context.Context, key string) (bool, error)
+}
+
+type imageLifecycle struct {
+	imageService       uploader.ImageService
+	deletionRepository DeletionRepository
+	references         ImageReferences
+	serviceToken       string
+}
+
+func NewImageLifecycle(imageService uploader.ImageService, deletionRepository DeletionRepository, references ImageReferences, serviceToken string) ImageLifecycle {
+	return &imageLifecycle{
+		imageService:       imageService,
+		deletionRepository: deletionRepository,
+		references:         references,
+		serviceToken:       serviceToken,
+	}
+}
+
+// Release deletes every key once, skipping keys that are still referenced.
+// The caller's token is used when present, otherwise the service token is.
+// Release must run after the write that dropped the keys has committed.
+func (l *imageLifecycle) Release(ctx context.Context, keys ...string) {
+
+	if _, ok := ctx.Value(constants.TokenKey).(string); !ok && l.serviceToken != "" {
+		ctx = context.WithValue(ctx, constants.TokenKey, l.serviceToken)
+	}
+
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		referenced, err := l.references.ImageKeyReferenced(ctx, key)
+		if referenced {
+			continue
+		}
+
+		if err == nil {
+			err = l.imageService.DeleteImageKey(ctx, key)
+			if err == nil {
+				continue
+			}
+		}
+
+		log.Printf("Error deleting image key %s, queued for retry: %v", key, err)
+
+		if err := l.deletionRepository.Enqueue(context.WithoutCancel(ctx), key, err.Error(), time.Now().Add(backoff(1))); err != nil {
+			log.Printf("Error queueing image key %s for deletion: %v", key, err)
+		}
+	}
+}
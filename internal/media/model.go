@@ -0,0 +1,25 @@
+package media
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusPending = "pending"
+	StatusFailed  = "failed"
+)
+
+// PendingDeletion is an image key whose removal from the media store failed
+// and is retried by the DeletionWorker until it succeeds or gives up.
+type PendingDeletion struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	Key           string             `json:"key" bson:"key"`
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"last_error" bson:"last_error"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+}
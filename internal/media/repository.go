@@ -0,0 +1,138 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeletionRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Enqueue(ctx context.Context, key string, lastError string, nextAttemptAt time.Time) error
+	ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*PendingDeletion, error)
+	Complete(ctx context.Context, id primitive.ObjectID) error
+	Reschedule(ctx context.Context, deletion *PendingDeletion) error
+}
+
+type deletionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewDeletionRepository(collection *mongo.Collection) DeletionRepository {
+	return &deletionRepository{
+		collection: collection,
+	}
+}
+
+func (r *deletionRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetName("unique_key").SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}},
+		},
+	})
+
+	return err
+}
+
+// Enqueue records a failed deletion. Enqueuing a key that is already queued
+// keeps a single entry and puts it back in the pending state.
+func (r *deletionRepository) Enqueue(ctx context.Context, key string, lastError string, nextAttemptAt time.Time) error {
+
+	now := time.Now()
+
+	filter := bson.M{"key": key}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          StatusPending,
+			"last_error":      lastError,
+			"next_attempt_at": nextAttemptAt,
+			"updated_at":      now,
+		},
+		"$inc": bson.M{"attempts": 1},
+		"$setOnInsert": bson.M{
+			"_id":        primitive.NewObjectID(),
+			"created_at": now,
+		},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// ClaimNext picks one due deletion and pushes its next attempt out by lease,
+// so concurrent workers never process the same key at the same time. It
+// returns nil when nothing is due.
+func (r *deletionRepository) ClaimNext(ctx context.Context, now time.Time, lease time.Duration) (*PendingDeletion, error) {
+
+	var deletion PendingDeletion
+
+	filter := bson.M{
+		"status":          StatusPending,
+		"next_attempt_at": bson.M{"$lte": now},
+	}
+
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&deletion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &deletion, nil
+
+}
+
+func (r *deletionRepository) Complete(ctx context.Context, id primitive.ObjectID) error {
+
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *deletionRepository) Reschedule(ctx context.Context, deletion *PendingDeletion) error {
+
+	filter := bson.M{"_id": deletion.ID}
+
+	update := bson.M{"$set": bson.M{
+		"status":          deletion.Status,
+		"attempts":        deletion.Attempts,
+		"last_error":      deletion.LastError,
+		"next_attempt_at": deletion.NextAttemptAt,
+		"updated_at":      time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
@@ -0,0 +1,120 @@
+package media
+
+import (
+	"context"
+	"log"
+	"product-service/pkg/constants"
+	"product-service/pkg/uploader"
+	"time"
+)
+
+const (
+	defaultMaxAttempts = 20
+	claimLease         = 5 * time.Minute
+	minBackoff         = 30 * time.Second
+	maxBackoff         = 6 * time.Hour
+)
+
+// DeletionWorker drains the pending deletion queue. Each failed attempt
+// doubles the wait before the next one; after maxAttempts the entry is kept
+// with the failed status for manual cleanup.
+type DeletionWorker struct {
+	deletionRepository DeletionRepository
+	imageService       uploader.ImageService
+	references         ImageReferences
+	serviceToken       string
+	interval           time.Duration
+	maxAttempts        int
+}
+
+func NewDeletionWorker(deletionRepository DeletionRepository, imageService uploader.ImageService, references ImageReferences, serviceToken string, interval time.Duration) *DeletionWorker {
+	return &DeletionWorker{
+		deletionRepository: deletionRepository,
+		imageService:       imageService,
+		references:         references,
+		serviceToken:       serviceToken,
+		interval:           interval,
+		maxAttempts:        defaultMaxAttempts,
+	}
+}
+
+// Run processes due deletions every interval until ctx is cancelled.
+func (w *DeletionWorker) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *DeletionWorker) drain(ctx context.Context) {
+
+	callCtx := context.WithValue(ctx, constants.TokenKey, w.serviceToken)
+
+	for ctx.Err() == nil {
+
+		deletion, err := w.deletionRepository.ClaimNext(ctx, time.Now(), claimLease)
+		if err != nil {
+			log.Println("Error claiming pending image deletion:", err)
+			return
+		}
+
+		if deletion == nil {
+			return
+		}
+
+		w.process(callCtx, deletion)
+	}
+}
+
+// process deletes the queued key unless a product has started using it
+// again since it was queued, in which case the entry is simply dropped.
+func (w *DeletionWorker) process(ctx context.Context, deletion *PendingDeletion) {
+
+	referenced, err := w.references.ImageKeyReferenced(ctx, deletion.Key)
+	if err == nil && !referenced {
+		err = w.imageService.DeleteImageKey(ctx, deletion.Key)
+	}
+
+	if err == nil {
+		if err := w.deletionRepository.Complete(ctx, deletion.ID); err != nil {
+			log.Println("Error completing image deletion:", err)
+		}
+		return
+	}
+
+	deletion.Attempts++
+	deletion.LastError = err.Error()
+	deletion.NextAttemptAt = time.Now().Add(backoff(deletion.Attempts))
+
+	if deletion.Attempts >= w.maxAttempts {
+		deletion.Status = StatusFailed
+		log.Printf("Giving up deleting image key %s after %d attempts: %v", deletion.Key, deletion.Attempts, err)
+	}
+
+	if err := w.deletionRepository.Reschedule(ctx, deletion); err != nil {
+		log.Println("Error rescheduling image deletion:", err)
+	}
+}
+
+func backoff(attempts int) time.Duration {
+
+	delay := minBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+
+	return delay
+}
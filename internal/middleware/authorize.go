@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"product-service/helper"
+	"product-service/internal/user"
+	"product-service/pkg/auth"
+	"product-service/pkg/constants"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Authorizer struct {
+	policy      *auth.Policy
+	userService user.UserService
+}
+
+func NewAuthorizer(policy *auth.Policy, userService user.UserService) *Authorizer {
+	return &Authorizer{
+		policy:      policy,
+		userService: userService,
+	}
+}
+
+// Require must run after Secured. Roles are read from the verified token and,
+// when the token carries none, from the user's profile in the main service.
+func (a *Authorizer) Require(permission string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+
+		roles, err := a.roles(ctx)
+		if err != nil {
+			forbidden(ctx, permission, fmt.Errorf("unable to resolve roles: %v", err))
+			return
+		}
+
+		if !a.policy.Allows(roles, permission) {
+			forbidden(ctx, permission, fmt.Errorf("roles [%s] are not granted %s", strings.Join(roles, ", "), permission))
+			return
+		}
+
+		ctx.Set(constants.Roles, roles)
+		ctx.Next()
+	}
+}
+
+func (a *Authorizer) roles(ctx *gin.Context) ([]string, error) {
+
+	if value, ok := ctx.Get(constants.Roles); ok {
+		if roles, ok := value.([]string); ok {
+			return roles, nil
+		}
+	}
+
+	if value, ok := ctx.Get(constants.Claims); ok {
+		if claims, ok := value.(jwt.MapClaims); ok {
+			if roles := rolesFromClaims(claims); len(roles) > 0 {
+				return roles, nil
+			}
+		}
+	}
+
+	userID := ctx.GetString(constants.UserID)
+	if userID == "" || a.userService == nil {
+		return nil, nil
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, ctx.GetString(constants.Token))
+
+	info, err := a.userService.GetUserInfor(c, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Role == "" {
+		return nil, nil
+	}
+
+	return []string{info.Role}, nil
+}
+
+func rolesFromClaims(claims jwt.MapClaims) []string {
+
+	var roles []string
+
+	if role, ok := claims[constants.Role].(string); ok && role != "" {
+		roles = append(roles, role)
+	}
+
+	if list, ok := claims[constants.Roles].([]interface{}); ok {
+		for _, item := range list {
+			if role, ok := item.(string); ok && role != "" {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	return roles
+}
+
+func forbidden(ctx *gin.Context, permission string, err error) {
+	helper.SendError(ctx, http.StatusForbidden, err, gin.H{"code": helper.ErrForbidden, "permission": permission})
+	ctx.Abort()
+}
@@ -1,38 +1,49 @@
 package middleware
 
 import (
-	"product-service/pkg/constants"
+	"errors"
 	"net/http"
+	"product-service/helper"
+	"product-service/pkg/auth"
+	"product-service/pkg/constants"
 	"strings"
+
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func Secured() gin.HandlerFunc {
+func Secured(verifier auth.Verifier) gin.HandlerFunc {
 	return func(context *gin.Context) {
 		authorizationHeader := context.GetHeader("Authorization")
 
 		if len(authorizationHeader) == 0 {
-			context.AbortWithStatus(http.StatusForbidden)
+			unauthorized(context, errors.New("authorization header is required"))
 			return
 		}
-		
+
 		if !strings.HasPrefix(authorizationHeader, "Bearer ") {
-			context.AbortWithStatus(http.StatusUnauthorized)
+			unauthorized(context, errors.New("authorization header must use the Bearer scheme"))
 			return
 		}
 
-		tokenString := strings.Split(authorizationHeader, " ")[1]
+		tokenString := strings.TrimSpace(strings.TrimPrefix(authorizationHeader, "Bearer "))
 
-		token, _, _ := new(jwt.Parser).ParseUnverified(tokenString, jwt.MapClaims{})
+		claims, err := verifier.Verify(tokenString)
+		if err != nil {
+			unauthorized(context, err)
+			return
+		}
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok {
-			if userId, ok := claims[constants.UserID].(string); ok {
-				context.Set(constants.UserID, userId)
-			}
+		if userId, ok := claims[constants.UserID].(string); ok {
+			context.Set(constants.UserID, userId)
 		}
 
+		context.Set(constants.Claims, claims)
 		context.Set(constants.Token, tokenString)
 		context.Next()
 	}
 }
+
+func unauthorized(context *gin.Context, err error) {
+	helper.SendError(context, http.StatusUnauthorized, err, gin.H{"code": helper.ErrUnauthorized})
+	context.Abort()
+}
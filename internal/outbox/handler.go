@@ -0,0 +1,54 @@
+package outbox
+
+import (
+	"errors"
+	"net/http"
+	"product-service/helper"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxHandler struct {
+	outboxService OutboxService
+}
+
+func NewOutboxHandler(outboxService OutboxService) *OutboxHandler {
+	return &OutboxHandler{
+		outboxService: outboxService,
+	}
+}
+
+func (h *OutboxHandler) GetStats(ctx *gin.Context) {
+
+	res, err := h.outboxService.Stats(ctx)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Outbox stats retrieved successfully", res)
+
+}
+
+func (h *OutboxHandler) Replay(ctx *gin.Context) {
+
+	var req ReplayRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.outboxService.Replay(ctx, &req)
+	if err != nil {
+		if errors.Is(err, ErrInvalidReplayRange) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Outbox entries queued for replay", res)
+
+}
@@ -0,0 +1,29 @@
+package outbox
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusPublished = "published"
+	StatusFailed    = "failed"
+)
+
+// Entry is one message waiting for, or kept after, delivery to the broker.
+// Entries are relayed in _id order.
+type Entry struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Topic         string             `bson:"topic"`
+	Key           string             `bson:"key"`
+	Value         string             `bson:"value"`
+	Headers       map[string]string  `bson:"headers"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	LastError     string             `bson:"last_error,omitempty"`
+	NextAttemptAt time.Time          `bson:"next_attempt_at"`
+	CreatedAt     time.Time          `bson:"created_at"`
+	PublishedAt   *time.Time         `bson:"published_at,omitempty"`
+}
@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"product-service/internal/events"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Publisher stores messages in the outbox instead of sending them. Used
+// with a transaction context, the messages are only queued if the change
+// that produced them commits; the Relay delivers them afterwards.
+type Publisher struct {
+	repository Repository
+}
+
+func NewPublisher(repository Repository) *Publisher {
+	return &Publisher{
+		repository: repository,
+	}
+}
+
+func (p *Publisher) Publish(ctx context.Context, messages ...events.Message) error {
+
+	if len(messages) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	entries := make([]*Entry, 0, len(messages))
+	for _, message := range messages {
+		entries = append(entries, &Entry{
+			ID:            primitive.NewObjectID(),
+			Topic:         message.Topic,
+			Key:           string(message.Key),
+			Value:         string(message.Value),
+			Headers:       message.Headers,
+			Status:        StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	return p.repository.Insert(ctx, entries)
+}
+
+func (p *Publisher) Close() error {
+	return nil
+}
@@ -0,0 +1,249 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"product-service/internal/events"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	leaseName          = "outbox-relay"
+	minLeaseTTL        = 30 * time.Second
+	relayBatchSize     = 100
+	defaultMaxAttempts = 20
+	minBackoff         = time.Second
+	maxBackoff         = 5 * time.Minute
+)
+
+// Relay publishes pending outbox entries to the broker in _id order. Only
+// the instance holding the relay lease publishes, so entries of one
+// aggregate leave in the order they were written. Delivery is at least
+// once: a crash between publishing and marking an entry sends it again.
+//
+// A failing entry blocks the ones behind it and is retried with a doubling
+// backoff; after maxAttempts it is set aside with the failed status so the
+// rest can flow, and can be sent again through Replay.
+type Relay struct {
+	repository  Repository
+	publisher   events.Publisher
+	interval    time.Duration
+	owner       string
+	leaseTTL    time.Duration
+	maxAttempts int
+
+	leader    atomic.Bool
+	published atomic.Int64
+	failures  atomic.Int64
+
+	mu              sync.Mutex
+	lastError       string
+	lastPublishedAt *time.Time
+}
+
+func NewRelay(repository Repository, publisher events.Publisher, interval time.Duration) *Relay {
+
+	hostname, _ := os.Hostname()
+
+	leaseTTL := 10 * interval
+	if leaseTTL < minLeaseTTL {
+		leaseTTL = minLeaseTTL
+	}
+
+	return &Relay{
+		repository:  repository,
+		publisher:   publisher,
+		interval:    interval,
+		owner:       fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), primitive.NewObjectID().Hex()),
+		leaseTTL:    leaseTTL,
+		maxAttempts: defaultMaxAttempts,
+	}
+}
+
+// Run relays due entries every interval until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stats reports the relay counters of this instance.
+func (r *Relay) Stats() RelayStats {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return RelayStats{
+		Leader:          r.leader.Load(),
+		Published:       r.published.Load(),
+		Failures:        r.failures.Load(),
+		LastError:       r.lastError,
+		LastPublishedAt: r.lastPublishedAt,
+	}
+}
+
+func (r *Relay) drain(ctx context.Context) {
+
+	for ctx.Err() == nil {
+
+		leader, err := r.repository.AcquireLease(ctx, leaseName, r.owner, r.leaseTTL)
+		if err != nil {
+			log.Println("Error acquiring outbox relay lease:", err)
+			r.leader.Store(false)
+			return
+		}
+
+		r.leader.Store(leader)
+		if !leader {
+			return
+		}
+
+		entries, err := r.repository.NextPending(ctx, relayBatchSize)
+		if err != nil {
+			log.Println("Error loading pending outbox entries:", err)
+			return
+		}
+
+		entries = due(entries, time.Now())
+		if len(entries) == 0 {
+			return
+		}
+
+		if !r.relay(ctx, entries) {
+			return
+		}
+	}
+}
+
+// due returns the leading entries whose next attempt has come. Entries
+// behind one that is waiting for a retry wait with it.
+func due(entries []*Entry, now time.Time) []*Entry {
+
+	for i, entry := range entries {
+		if entry.NextAttemptAt.After(now) {
+			return entries[:i]
+		}
+	}
+
+	return entries
+}
+
+// relay publishes entries as one batch, falling back to one at a time when
+// the batch fails so that the entries before the culprit still go out. It
+// reports whether every entry was dealt with.
+func (r *Relay) relay(ctx context.Context, entries []*Entry) bool {
+
+	if err := r.publisher.Publish(ctx, toMessages(entries)...); err == nil {
+		r.markPublished(ctx, entries)
+		return true
+	}
+
+	for _, entry := range entries {
+
+		err := r.publisher.Publish(ctx, toMessages([]*Entry{entry})...)
+		if err != nil {
+			if r.fail(ctx, entry, err) {
+				continue
+			}
+			return false
+		}
+
+		r.markPublished(ctx, []*Entry{entry})
+	}
+
+	return true
+}
+
+func (r *Relay) markPublished(ctx context.Context, entries []*Entry) {
+
+	now := time.Now()
+
+	ids := make([]primitive.ObjectID, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+
+	if err := r.repository.MarkPublished(ctx, ids, now); err != nil {
+		log.Println("Error marking outbox entries published:", err)
+	}
+
+	r.published.Add(int64(len(entries)))
+
+	r.mu.Lock()
+	r.lastPublishedAt = &now
+	r.mu.Unlock()
+}
+
+// fail records a failed attempt for entry and reports whether it was set
+// aside, letting the entries behind it go.
+func (r *Relay) fail(ctx context.Context, entry *Entry, err error) bool {
+
+	r.failures.Add(1)
+
+	r.mu.Lock()
+	r.lastError = err.Error()
+	r.mu.Unlock()
+
+	entry.Attempts++
+	entry.LastError = err.Error()
+	entry.NextAttemptAt = time.Now().Add(backoff(entry.Attempts))
+
+	if entry.Attempts >= r.maxAttempts {
+		entry.Status = StatusFailed
+		log.Printf("Giving up publishing outbox entry %s to %s after %d attempts: %v", entry.ID.Hex(), entry.Topic, entry.Attempts, err)
+	} else {
+		log.Printf("Error publishing outbox entry %s to %s (attempt %d): %v", entry.ID.Hex(), entry.Topic, entry.Attempts, err)
+	}
+
+	if err := r.repository.Reschedule(ctx, entry); err != nil {
+		log.Println("Error rescheduling outbox entry:", err)
+		return false
+	}
+
+	return entry.Status == StatusFailed
+}
+
+func toMessages(entries []*Entry) []events.Message {
+
+	messages := make([]events.Message, 0, len(entries))
+	for _, entry := range entries {
+		messages = append(messages, events.Message{
+			Topic:   entry.Topic,
+			Key:     []byte(entry.Key),
+			Value:   []byte(entry.Value),
+			Headers: entry.Headers,
+		})
+	}
+
+	return messages
+}
+
+func backoff(attempts int) time.Duration {
+
+	delay := minBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+
+	return delay
+}
@@ -0,0 +1,241 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	EnsureIndexes(ctx context.Context, retention time.Duration) error
+	Insert(ctx context.Context, entries []*Entry) error
+	NextPending(ctx context.Context, limit int64) ([]*Entry, error)
+	MarkPublished(ctx context.Context, ids []primitive.ObjectID, at time.Time) error
+	Reschedule(ctx context.Context, entry *Entry) error
+	CountByStatus(ctx context.Context) (map[string]int64, error)
+	OldestPending(ctx context.Context) (*Entry, error)
+	Replay(ctx context.Context, filter bson.M, now time.Time) (int64, error)
+	AcquireLease(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error)
+}
+
+type repository struct {
+	collection *mongo.Collection
+	leases     *mongo.Collection
+}
+
+func NewRepository(collection *mongo.Collection, leases *mongo.Collection) Repository {
+	return &repository{
+		collection: collection,
+		leases:     leases,
+	}
+}
+
+// EnsureIndexes creates the relay index and a TTL index that drops
+// published entries after retention. Pending and failed entries never
+// expire.
+func (r *repository) EnsureIndexes(ctx context.Context, retention time.Duration) error {
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "created_at", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetName("published_ttl").SetExpireAfterSeconds(int32(retention.Seconds())),
+		},
+	})
+
+	return err
+}
+
+// Insert writes entries with the caller's context, so inside a transaction
+// they commit or roll back together with the change they describe.
+func (r *repository) Insert(ctx context.Context, entries []*Entry) error {
+
+	docs := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		docs = append(docs, entry)
+	}
+
+	_, err := r.collection.InsertMany(ctx, docs)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *repository) NextPending(ctx context.Context, limit int64) ([]*Entry, error) {
+
+	var entries []*Entry
+
+	filter := bson.M{"status": StatusPending}
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+
+}
+
+func (r *repository) MarkPublished(ctx context.Context, ids []primitive.ObjectID, at time.Time) error {
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+
+	update := bson.M{
+		"$set":   bson.M{"status": StatusPublished, "published_at": at},
+		"$unset": bson.M{"last_error": ""},
+	}
+
+	_, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *repository) Reschedule(ctx context.Context, entry *Entry) error {
+
+	filter := bson.M{"_id": entry.ID}
+
+	update := bson.M{"$set": bson.M{
+		"status":          entry.Status,
+		"attempts":        entry.Attempts,
+		"last_error":      entry.LastError,
+		"next_attempt_at": entry.NextAttemptAt,
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *repository) CountByStatus(ctx context.Context) (map[string]int64, error) {
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$status", "count": bson.M{"$sum": 1}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		Status string `bson:"_id"`
+		Count  int64  `bson:"count"`
+	}
+
+	err = cursor.All(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+
+	return counts, nil
+
+}
+
+// OldestPending returns the entry the relay will deliver next, or nil when
+// nothing is pending.
+func (r *repository) OldestPending(ctx context.Context) (*Entry, error) {
+
+	var entry Entry
+
+	filter := bson.M{"status": StatusPending}
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	err := r.collection.FindOne(ctx, filter, opts).Decode(&entry)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &entry, nil
+
+}
+
+// Replay puts the entries matching filter back in the pending state with a
+// fresh attempt budget.
+func (r *repository) Replay(ctx context.Context, filter bson.M, now time.Time) (int64, error) {
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		},
+		"$unset": bson.M{"published_at": "", "last_error": ""},
+	}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+
+}
+
+// AcquireLease takes or renews the named lease for owner until ttl from
+// now. It fails without error while another owner holds an unexpired
+// lease.
+func (r *repository) AcquireLease(ctx context.Context, name string, owner string, ttl time.Duration) (bool, error) {
+
+	now := time.Now()
+
+	filter := bson.M{
+		"_id": name,
+		"$or": bson.A{
+			bson.M{"owner": owner},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		},
+	}
+
+	update := bson.M{"$set": bson.M{
+		"owner":      owner,
+		"expires_at": now.Add(ttl),
+	}}
+
+	_, err := r.leases.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+
+}
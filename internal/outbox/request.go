@@ -0,0 +1,13 @@
+package outbox
+
+import "time"
+
+// ReplayRequest selects entries to publish again by id or creation time.
+// Bounds are inclusive and at least one lower bound is required.
+type ReplayRequest struct {
+	FromID string     `json:"from_id"`
+	ToID   string     `json:"to_id"`
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+	Topic  string     `json:"topic"`
+}
@@ -0,0 +1,27 @@
+package outbox
+
+import "time"
+
+type StatsResponse struct {
+	Pending int64 `json:"pending"`
+	Failed  int64 `json:"failed"`
+	// Published counts delivered entries still within the retention window.
+	Published       int64      `json:"published"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LagSeconds      float64    `json:"lag_seconds"`
+	Relay           RelayStats `json:"relay"`
+}
+
+// RelayStats are counters of the relay in the instance that answered,
+// since it started.
+type RelayStats struct {
+	Leader          bool       `json:"leader"`
+	Published       int64      `json:"published"`
+	Failures        int64      `json:"failures"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastPublishedAt *time.Time `json:"last_published_at,omitempty"`
+}
+
+type ReplayResponse struct {
+	Replayed int64 `json:"replayed"`
+}
@@ -0,0 +1,16 @@
+package outbox
+
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, outboxHandler *OutboxHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	outboxGroup := r.Group("api/v1/admin/outbox", middleware.Secured(verifier))
+	{
+		outboxGroup.GET("/stats", authorizer.Require(auth.OutboxRead), outboxHandler.GetStats)
+		outboxGroup.POST("/replay", authorizer.Require(auth.OutboxReplay), outboxHandler.Replay)
+	}
+}
@@ -0,0 +1,135 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type OutboxService interface {
+	Stats(ctx context.Context) (*StatsResponse, error)
+	Replay(ctx context.Context, req *ReplayRequest) (*ReplayResponse, error)
+}
+
+var ErrInvalidReplayRange = errors.New("replay needs from_id or from, with bounds in order")
+
+type outboxService struct {
+	repository Repository
+	relay      *Relay
+}
+
+func NewOutboxService(repository Repository, relay *Relay) OutboxService {
+	return &outboxService{
+		repository: repository,
+		relay:      relay,
+	}
+}
+
+func (s *outboxService) Stats(ctx context.Context) (*StatsResponse, error) {
+
+	counts, err := s.repository.CountByStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	oldest, err := s.repository.OldestPending(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &StatsResponse{
+		Pending:   counts[StatusPending],
+		Failed:    counts[StatusFailed],
+		Published: counts[StatusPublished],
+		Relay:     s.relay.Stats(),
+	}
+
+	if oldest != nil {
+		res.OldestPendingAt = &oldest.CreatedAt
+		res.LagSeconds = time.Since(oldest.CreatedAt).Seconds()
+	}
+
+	return res, nil
+
+}
+
+// Replay sends the selected entries again, whatever their status. Entries
+// already removed by the retention TTL cannot be replayed.
+func (s *outboxService) Replay(ctx context.Context, req *ReplayRequest) (*ReplayResponse, error) {
+
+	filter, err := replayFilter(req)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed, err := s.repository.Replay(ctx, filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayResponse{Replayed: replayed}, nil
+
+}
+
+func replayFilter(req *ReplayRequest) (bson.M, error) {
+
+	if req.FromID == "" && req.From == nil {
+		return nil, ErrInvalidReplayRange
+	}
+
+	filter := bson.M{}
+
+	if req.FromID != "" || req.ToID != "" {
+		ids := bson.M{}
+
+		var fromID primitive.ObjectID
+		if req.FromID != "" {
+			id, err := primitive.ObjectIDFromHex(req.FromID)
+			if err != nil {
+				return nil, ErrInvalidReplayRange
+			}
+			fromID = id
+			ids["$gte"] = fromID
+		}
+
+		if req.ToID != "" {
+			toID, err := primitive.ObjectIDFromHex(req.ToID)
+			if err != nil {
+				return nil, ErrInvalidReplayRange
+			}
+			if bytes.Compare(toID[:], fromID[:]) < 0 {
+				return nil, ErrInvalidReplayRange
+			}
+			ids["$lte"] = toID
+		}
+
+		filter["_id"] = ids
+	}
+
+	if req.From != nil || req.To != nil {
+		created := bson.M{}
+
+		if req.From != nil {
+			created["$gte"] = *req.From
+		}
+
+		if req.To != nil {
+			if req.From != nil && req.To.Before(*req.From) {
+				return nil, ErrInvalidReplayRange
+			}
+			created["$lte"] = *req.To
+		}
+
+		filter["created_at"] = created
+	}
+
+	if req.Topic != "" {
+		filter["topic"] = req.Topic
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,61 @@
+package product
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+type ProductChange struct {
+	ID   primitive.ObjectID
+	Type string
+	// Product is the stored state after the write, or before it for a
+	// delete. Partial updates leave it nil; listeners that need the whole
+	// product load it themselves.
+	Product *Product
+}
+
+// ChangeListener is told about every product write made through the
+// product service. It runs inside the write's transaction, when the service
+// has one, with ctx bound to it: an error aborts the write, and anything
+// stored with ctx commits with it. Without a transaction an error fails the
+// request but the write stays. Implementations must not block the request;
+// slow work belongs on a queue of their own.
+type ChangeListener interface {
+	ProductChanged(ctx context.Context, change ProductChange) error
+}
+
+// write runs fn and notifies the listeners through the transaction runner,
+// which only opens a transaction when a listener needs one.
+func (s *productService) write(ctx context.Context, changeType string, id primitive.ObjectID, product *Product, fn func(ctx context.Context) error) error {
+
+	return s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+
+		if err := fn(ctx); err != nil {
+			return err
+		}
+
+		return s.notify(ctx, changeType, id, product)
+	})
+}
+
+func (s *productService) notify(ctx context.Context, changeType string, id primitive.ObjectID, product *Product) error {
+	return notifyListeners(ctx, s.listeners, ProductChange{ID: id, Type: changeType, Product: product})
+}
+
+func notifyListeners(ctx context.Context, listeners []ChangeListener, change ProductChange) error {
+
+	for _, listener := range listeners {
+		if err := listener.ProductChanged(ctx, change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
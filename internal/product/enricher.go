@@ -0,0 +1,348 @@
+package product
+
+import (
+	"context"
+	"log"
+	"product-service/internal/folder"
+	"product-service/internal/promotion"
+	"product-service/internal/shared/ports"
+	"product-service/internal/topic"
+	"product-service/pkg/uploader"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultEnrichConcurrency = 8
+
+// ProductEnricher turns stored products into API responses. Folder, topic and
+// image lookups are collected for the whole batch, deduplicated and resolved
+// once per call, so a page of products costs one folder query plus a bounded
+// number of concurrent remote calls.
+type ProductEnricher interface {
+	Enrich(ctx context.Context, products []*Product) []*ProductResponse
+	EnrichOne(ctx context.Context, product *Product) *ProductResponse
+	ResolveImages(ctx context.Context, keys []string) map[string]string
+	ResolveTopics(ctx context.Context, ids []string) map[string]*topic.Topic
+}
+
+type productEnricher struct {
+	folderRepository ports.FolderRepository
+	topicService     topic.TopicService
+	imageService     uploader.ImageService
+	promotionService promotion.PromotionService
+	concurrency      int
+}
+
+type enrichment struct {
+	folders    map[primitive.ObjectID]*folder.Folder
+	topics     map[string]*topic.Topic
+	images     map[string]string
+	promotions []*promotion.Promotion
+	now        time.Time
+}
+
+func NewProductEnricher(folderRepository ports.FolderRepository, topicService topic.TopicService, imageService uploader.ImageService, promotionService promotion.PromotionService) ProductEnricher {
+	return &productEnricher{
+		folderRepository: folderRepository,
+		topicService:     topicService,
+		imageService:     imageService,
+		promotionService: promotionService,
+		concurrency:      defaultEnrichConcurrency,
+	}
+}
+
+func (e *productEnricher) EnrichOne(ctx context.Context, product *Product) *ProductResponse {
+	return e.Enrich(ctx, []*Product{product})[0]
+}
+
+func (e *productEnricher) Enrich(ctx context.Context, products []*Product) []*ProductResponse {
+
+	folderIDs := make(map[primitive.ObjectID]struct{})
+	topicIDs := make(map[string]struct{})
+	imageKeys := make(map[string]struct{})
+
+	for _, product := range products {
+		folderIDs[product.FolderID] = struct{}{}
+		topicIDs[product.TopicID.Hex()] = struct{}{}
+		if product.CoverImage != "" {
+			imageKeys[product.CoverImage] = struct{}{}
+		}
+		for _, key := range variationImageKeys(product.Variations) {
+			imageKeys[key] = struct{}{}
+		}
+		for _, key := range galleryImageKeys(product.Images) {
+			imageKeys[key] = struct{}{}
+		}
+	}
+
+	data := enrichment{now: time.Now()}
+	var wg sync.WaitGroup
+
+	wg.Add(3)
+
+	// Folder promotions apply to subfolders too, so promotions are looked up
+	// once the folders give the ancestors of every product's folder.
+	go func() {
+		defer wg.Done()
+		data.folders = e.resolveFolders(ctx, folderIDs)
+		data.promotions = e.resolvePromotions(ctx, products, data.folderPaths(folderIDs), data.now)
+	}()
+
+	go func() {
+		defer wg.Done()
+		data.topics = e.resolveTopics(ctx, topicIDs)
+	}()
+
+	go func() {
+		defer wg.Done()
+		data.images = e.resolveImages(ctx, imageKeys)
+	}()
+
+	wg.Wait()
+
+	responses := make([]*ProductResponse, 0, len(products))
+	for _, product := range products {
+		responses = append(responses, data.toResponse(product))
+	}
+
+	return responses
+}
+
+func (e *productEnricher) ResolveImages(ctx context.Context, keys []string) map[string]string {
+
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		if key != "" {
+			set[key] = struct{}{}
+		}
+	}
+
+	return e.resolveImages(ctx, set)
+}
+
+func (e *productEnricher) ResolveTopics(ctx context.Context, ids []string) map[string]*topic.Topic {
+
+	set := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			set[id] = struct{}{}
+		}
+	}
+
+	return e.resolveTopics(ctx, set)
+}
+
+func (e *productEnricher) resolveTopics(ctx context.Context, ids map[string]struct{}) map[string]*topic.Topic {
+	return resolveConcurrently(ctx, ids, e.concurrency, func(ctx context.Context, id string) (*topic.Topic, bool) {
+		topic, err := e.topicService.GetTopicByID(ctx, id)
+		if err != nil {
+			log.Println("Error getting topic:", err)
+			return nil, false
+		}
+		return topic, topic != nil
+	})
+}
+
+func (e *productEnricher) resolveImages(ctx context.Context, keys map[string]struct{}) map[string]string {
+	return resolveConcurrently(ctx, keys, e.concurrency, func(ctx context.Context, key string) (string, bool) {
+		img, err := e.imageService.GetImageKey(ctx, key)
+		if err != nil {
+			log.Println("Error getting image key:", err)
+			return "", false
+		}
+		if img == nil {
+			return "", false
+		}
+		return img.Url, true
+	})
+}
+
+func (e *productEnricher) resolveFolders(ctx context.Context, ids map[primitive.ObjectID]struct{}) map[primitive.ObjectID]*folder.Folder {
+
+	result := make(map[primitive.ObjectID]*folder.Folder, len(ids))
+	if len(ids) == 0 {
+		return result
+	}
+
+	list := make([]primitive.ObjectID, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+
+	folders, err := e.folderRepository.GetFoldersByIDs(ctx, list)
+	if err != nil {
+		log.Println("Error getting folders:", err)
+		return result
+	}
+
+	for _, f := range folders {
+		result[f.ID] = f
+	}
+
+	return result
+}
+
+func (e *productEnricher) resolvePromotions(ctx context.Context, products []*Product, folderIDs []primitive.ObjectID, at time.Time) []*promotion.Promotion {
+
+	productIDs := make([]primitive.ObjectID, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+	}
+
+	promotions, err := e.promotionService.GetActivePromotions(ctx, productIDs, folderIDs, at)
+	if err != nil {
+		log.Println("Error getting promotions:", err)
+		return nil
+	}
+
+	return promotions
+}
+
+// resolveConcurrently calls fn once per key with at most limit calls in
+// flight and collects the values fn reports as found.
+func resolveConcurrently[K comparable, V any](ctx context.Context, keys map[K]struct{}, limit int, fn func(ctx context.Context, key K) (V, bool)) map[K]V {
+
+	result := make(map[K]V, len(keys))
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+
+	for key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(key K) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			value, ok := fn(ctx, key)
+			if !ok {
+				return
+			}
+
+			mu.Lock()
+			result[key] = value
+			mu.Unlock()
+		}(key)
+	}
+
+	wg.Wait()
+
+	return result
+}
+
+// folderPaths lists the given folders together with the ancestors of those
+// that resolved.
+func (d *enrichment) folderPaths(folderIDs map[primitive.ObjectID]struct{}) []primitive.ObjectID {
+
+	seen := make(map[primitive.ObjectID]struct{})
+	ids := make([]primitive.ObjectID, 0, len(folderIDs))
+
+	for id := range folderIDs {
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	for _, f := range d.folders {
+		for _, crumb := range f.Ancestors {
+			if _, ok := seen[crumb.ID]; ok {
+				continue
+			}
+			seen[crumb.ID] = struct{}{}
+			ids = append(ids, crumb.ID)
+		}
+	}
+
+	return ids
+}
+
+// folderPath returns the IDs from the root down to the product's folder,
+// which are the folders whose promotions apply to it.
+func (d *enrichment) folderPath(product *Product) []primitive.ObjectID {
+
+	folder, ok := d.folders[product.FolderID]
+	if !ok {
+		return []primitive.ObjectID{product.FolderID}
+	}
+
+	breadcrumb := folder.Breadcrumb()
+
+	ids := make([]primitive.ObjectID, 0, len(breadcrumb))
+	for _, crumb := range breadcrumb {
+		ids = append(ids, crumb.ID)
+	}
+
+	return ids
+}
+
+func (d *enrichment) toResponse(product *Product) *ProductResponse {
+
+	topicResp := &Topic{}
+	if topic, ok := d.topics[product.TopicID.Hex()]; ok {
+		topicResp.ID = topic.ID
+		topicResp.Name = topic.Name
+	}
+
+	folderResp := Folder{}
+	if folder, ok := d.folders[product.FolderID]; ok {
+		folderResp = Folder{
+			ID:   folder.ID.Hex(),
+			Name: folder.Name,
+		}
+		for _, crumb := range folder.Breadcrumb() {
+			folderResp.Breadcrumb = append(folderResp.Breadcrumb, Breadcrumb{
+				ID:   crumb.ID.Hex(),
+				Name: crumb.Name,
+			})
+		}
+	}
+
+	return &ProductResponse{
+		ID:                 product.ID,
+		ProductName:        product.ProductName,
+		OriginPriceStore:   product.OriginPriceStore,
+		OriginPriceService: product.OriginPriceService,
+		EffectivePrice:     d.effectivePrice(product),
+		ProductDescription: product.ProductDescription,
+		CoverImage:         d.images[product.CoverImage],
+		Topic:              topicResp,
+		Folder:             folderResp,
+		QRCode:             product.QRCode,
+		Variations:         toVariationResponses(product.Variations, d.images),
+		Specifications:     product.Specifications,
+		UsageConfig:        product.UsageConfig,
+		Images:             toImageResponses(product.Images, d.images),
+		VideoUrl:           product.VideoUrl,
+		CreatedAt:          product.CreatedAt,
+		UpdatedAt:          product.UpdatedAt,
+	}
+}
+
+func (d *enrichment) effectivePrice(product *Product) EffectivePrice {
+
+	price := promotion.Resolve(d.promotions, product.ID, d.folderPath(product), product.OriginPriceStore, product.OriginPriceService, d.now)
+
+	res := EffectivePrice{
+		PriceStore:   price.PriceStore,
+		PriceService: price.PriceService,
+	}
+
+	if p := price.Promotion; p != nil {
+		res.Promotion = &AppliedPromotion{
+			ID:                   p.ID.Hex(),
+			Name:                 p.Name,
+			DiscountType:         p.DiscountType,
+			DiscountOff:          p.DiscountOff,
+			DiscountPriceStore:   p.DiscountPriceStore,
+			DiscountPriceService: p.DiscountPriceService,
+			PurchaseLimit:        p.PurchaseLimit,
+			PromotionStock:       p.PromotionStock,
+			EndAt:                p.EndAt,
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,82 @@
+package product
+
+import (
+	"context"
+	"product-service/internal/folder"
+	"product-service/internal/promotion"
+	"product-service/internal/topic"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeTopicService struct{}
+
+func (fakeTopicService) GetTopicByID(ctx context.Context, id string) (*topic.Topic, error) {
+	return nil, nil
+}
+
+// fakePromotionService returns the stored promotions of the requested
+// folders, as the repository query does.
+type fakePromotionService struct {
+	promotion.PromotionService
+	promotions []*promotion.Promotion
+}
+
+func (s *fakePromotionService) GetActivePromotions(ctx context.Context, productIDs []primitive.ObjectID, folderIDs []primitive.ObjectID, at time.Time) ([]*promotion.Promotion, error) {
+
+	var promotions []*promotion.Promotion
+	for _, p := range s.promotions {
+		if p.FolderID != nil && containsID(folderIDs, *p.FolderID) {
+			promotions = append(promotions, p)
+		}
+	}
+
+	return promotions, nil
+}
+
+func TestEnrichAppliesAncestorFolderPromotions(t *testing.T) {
+
+	rootID := primitive.NewObjectID()
+	parentID := primitive.NewObjectID()
+	child := &folder.Folder{
+		ID:   primitive.NewObjectID(),
+		Name: "Bulbs",
+		Ancestors: []folder.FolderAncestor{
+			{ID: rootID, Name: "Home"},
+			{ID: parentID, Name: "Lighting"},
+		},
+	}
+
+	now := time.Now()
+	sale := &promotion.Promotion{
+		ID:              primitive.NewObjectID(),
+		Name:            "Lighting sale",
+		FolderID:        &parentID,
+		DiscountType:    promotion.TypePercentage,
+		DiscountOff:     25,
+		DiscountEnabled: true,
+		StartAt:         now.Add(-time.Hour),
+		EndAt:           now.Add(time.Hour),
+	}
+
+	enricher := NewProductEnricher(
+		&fakeFolderRepository{folders: []*folder.Folder{child}},
+		fakeTopicService{},
+		nil,
+		&fakePromotionService{promotions: []*promotion.Promotion{sale}},
+	)
+
+	product := &Product{ID: primitive.NewObjectID(), FolderID: child.ID, OriginPriceStore: 100, OriginPriceService: 40}
+
+	res := enricher.EnrichOne(context.Background(), product)
+
+	price := res.EffectivePrice
+	if price.Promotion == nil || price.Promotion.ID != sale.ID.Hex() {
+		t.Fatalf("applied promotion = %+v, want the parent folder's", price.Promotion)
+	}
+	if price.PriceStore != 75 || price.PriceService != 30 {
+		t.Errorf("effective prices = %v/%v, want 75/30", price.PriceStore, price.PriceService)
+	}
+}
@@ -0,0 +1,297 @@
+package product
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+	ExportFormatJSON = "json"
+
+	// exportBatchSize is how many products are enriched at once while the
+	// export cursor is streamed.
+	exportBatchSize = 200
+	exportSheetName = "Products"
+)
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportFormatJSON: "application/json; charset=utf-8",
+}
+
+// Column names line up with the import fields where they carry the same
+// value, so an export can be edited and imported again.
+var exportHeader = []string{
+	"id",
+	"product_name",
+	"folder_id",
+	"folder",
+	"topic_id",
+	"topic",
+	"origin_price_store",
+	"origin_price_service",
+	"qrcode",
+	"cover_url",
+	"created_at",
+	"updated_at",
+}
+
+type ExportRow struct {
+	ID                 string    `json:"id"`
+	ProductName        string    `json:"product_name"`
+	FolderID           string    `json:"folder_id"`
+	FolderPath         string    `json:"folder"`
+	TopicID            string    `json:"topic_id"`
+	TopicName          string    `json:"topic"`
+	OriginPriceStore   float64   `json:"origin_price_store"`
+	OriginPriceService float64   `json:"origin_price_service"`
+	QRCode             string    `json:"qrcode"`
+	CoverUrl           string    `json:"cover_url"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// ExportContentType returns the content type and file extension of format.
+func ExportContentType(format string) (string, string, error) {
+
+	if format == "" {
+		format = ExportFormatCSV
+	}
+
+	contentType, ok := exportContentTypes[format]
+	if !ok {
+		return "", "", fmt.Errorf("%w: %s", ErrUnsupportedExportFormat, format)
+	}
+
+	return contentType, format, nil
+}
+
+func toExportRow(product *Product, res *ProductResponse) *ExportRow {
+
+	names := make([]string, 0, len(res.Folder.Breadcrumb))
+	for _, crumb := range res.Folder.Breadcrumb {
+		names = append(names, crumb.Name)
+	}
+
+	row := &ExportRow{
+		ID:                 product.ID.Hex(),
+		ProductName:        product.ProductName,
+		FolderID:           product.FolderID.Hex(),
+		FolderPath:         strings.Join(names, " > "),
+		TopicID:            product.TopicID.Hex(),
+		OriginPriceStore:   product.OriginPriceStore,
+		OriginPriceService: product.OriginPriceService,
+		QRCode:             product.QRCode,
+		CoverUrl:           res.CoverImage,
+		CreatedAt:          product.CreatedAt,
+		UpdatedAt:          product.UpdatedAt,
+	}
+
+	if res.Topic != nil {
+		row.TopicName = res.Topic.Name
+	}
+
+	return row
+}
+
+func (r *ExportRow) values() []string {
+	return []string{
+		r.ID,
+		r.ProductName,
+		r.FolderID,
+		r.FolderPath,
+		r.TopicID,
+		r.TopicName,
+		strconv.FormatFloat(r.OriginPriceStore, 'f', -1, 64),
+		strconv.FormatFloat(r.OriginPriceService, 'f', -1, 64),
+		r.QRCode,
+		r.CoverUrl,
+		formatExportTime(r.CreatedAt),
+		formatExportTime(r.UpdatedAt),
+	}
+}
+
+func formatExportTime(t time.Time) string {
+
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// exportWriter streams rows in one output format. Close finishes the
+// document once every row has been written; Discard releases the writer
+// after a failed export without finishing it.
+type exportWriter interface {
+	Write(row *ExportRow) error
+	Close() error
+	Discard()
+}
+
+func newExportWriter(format string, w io.Writer) (exportWriter, error) {
+
+	switch format {
+	case "", ExportFormatCSV:
+		return newCSVExportWriter(w)
+	case ExportFormatJSON:
+		return &jsonExportWriter{w: w}, nil
+	case ExportFormatXLSX:
+		return newXLSXExportWriter(w)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedExportFormat, format)
+	}
+}
+
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func newCSVExportWriter(w io.Writer) (*csvExportWriter, error) {
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return nil, err
+	}
+
+	return &csvExportWriter{w: writer}, nil
+}
+
+func (c *csvExportWriter) Write(row *ExportRow) error {
+	return c.w.Write(row.values())
+}
+
+func (c *csvExportWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvExportWriter) Discard() {}
+
+// jsonExportWriter writes a single JSON array one element at a time.
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) Write(row *ExportRow) error {
+
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+
+	prefix := ","
+	if j.count == 0 {
+		prefix = "["
+	}
+	j.count++
+
+	if _, err := io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonExportWriter) Close() error {
+
+	end := "]"
+	if j.count == 0 {
+		end = "[]"
+	}
+
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+func (j *jsonExportWriter) Discard() {}
+
+// xlsxExportWriter uses the excelize stream writer, which spills rows to a
+// temporary file instead of keeping the whole sheet in memory. The workbook
+// is only written to w on Close.
+type xlsxExportWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXExportWriter(w io.Writer) (*xlsxExportWriter, error) {
+
+	file := excelize.NewFile()
+
+	if err := file.SetSheetName("Sheet1", exportSheetName); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream, err := file.NewStreamWriter(exportSheetName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	header := make([]interface{}, len(exportHeader))
+	for i, name := range exportHeader {
+		header[i] = name
+	}
+
+	if err := stream.SetRow("A1", header); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &xlsxExportWriter{w: w, file: file, stream: stream, row: 1}, nil
+}
+
+func (x *xlsxExportWriter) Write(row *ExportRow) error {
+
+	x.row++
+
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+
+	values := row.values()
+	cells := make([]interface{}, len(values))
+	for i, value := range values {
+		cells[i] = value
+	}
+
+	// Prices stay numeric so they can be summed in the spreadsheet.
+	cells[6] = row.OriginPriceStore
+	cells[7] = row.OriginPriceService
+
+	return x.stream.SetRow(cell, cells)
+}
+
+func (x *xlsxExportWriter) Close() error {
+
+	defer x.file.Close()
+
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+
+	return x.file.Write(x.w)
+}
+
+func (x *xlsxExportWriter) Discard() {
+	x.file.Close()
+}
@@ -0,0 +1,142 @@
+package product
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPriceBuckets = 5
+	maxPriceBuckets     = 20
+)
+
+// createdRanges are cumulative: a product created yesterday counts in every
+// range.
+var createdRanges = []struct {
+	Key  string
+	Days int
+}{
+	{Key: "last_7_days", Days: 7},
+	{Key: "last_30_days", Days: 30},
+	{Key: "last_90_days", Days: 90},
+	{Key: "last_365_days", Days: 365},
+}
+
+type FacetQuery struct {
+	PriceBuckets int
+	Now          time.Time
+}
+
+// FacetCounts is the raw result of the facet aggregation, keyed by id.
+type FacetCounts struct {
+	Folders      []IDCount        `bson:"folders"`
+	Topics       []IDCount        `bson:"topics"`
+	PriceStore   []PriceBucket    `bson:"price_store"`
+	PriceService []PriceBucket    `bson:"price_service"`
+	Created      []map[string]int `bson:"created"`
+}
+
+type IDCount struct {
+	ID    primitive.ObjectID `bson:"_id"`
+	Count int64              `bson:"count"`
+}
+
+type PriceBucket struct {
+	Range struct {
+		Min float64 `bson:"min"`
+		Max float64 `bson:"max"`
+	} `bson:"_id"`
+	Count int64 `bson:"count"`
+}
+
+// facetStages builds the $facet sub-pipelines that run next to the page
+// over the same filtered documents.
+func facetStages(query *FacetQuery) bson.M {
+
+	buckets := query.PriceBuckets
+	if buckets <= 0 {
+		buckets = defaultPriceBuckets
+	}
+	if buckets > maxPriceBuckets {
+		buckets = maxPriceBuckets
+	}
+
+	created := bson.M{"_id": nil}
+	for _, r := range createdRanges {
+		from := query.Now.AddDate(0, 0, -r.Days)
+		created[r.Key] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$created_at", from}}, 1, 0}}}
+	}
+
+	countBy := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$group": bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+		}
+	}
+
+	priceBuckets := func(field string) bson.A {
+		return bson.A{
+			bson.M{"$bucketAuto": bson.M{"groupBy": "$" + field, "buckets": buckets}},
+		}
+	}
+
+	return bson.M{
+		"folders":       countBy("folder_id"),
+		"topics":        countBy("topic_id"),
+		"price_store":   priceBuckets("original_price_store"),
+		"price_service": priceBuckets("original_price_service"),
+		"created": bson.A{
+			bson.M{"$group": created},
+			bson.M{"$project": bson.M{"_id": 0}},
+		},
+	}
+}
+
+func toFacetValues(counts []IDCount, names map[primitive.ObjectID]string) []FacetValue {
+
+	values := make([]FacetValue, 0, len(counts))
+	for _, c := range counts {
+		values = append(values, FacetValue{
+			ID:    c.ID.Hex(),
+			Name:  names[c.ID],
+			Count: c.Count,
+		})
+	}
+
+	return values
+}
+
+func toPriceFacets(buckets []PriceBucket) []PriceFacet {
+
+	facets := make([]PriceFacet, 0, len(buckets))
+	for _, b := range buckets {
+		facets = append(facets, PriceFacet{
+			Min:   b.Range.Min,
+			Max:   b.Range.Max,
+			Count: b.Count,
+		})
+	}
+
+	return facets
+}
+
+func toCreatedFacets(created []map[string]int, now time.Time) []DateRangeFacet {
+
+	counts := map[string]int{}
+	if len(created) > 0 {
+		counts = created[0]
+	}
+
+	facets := make([]DateRangeFacet, 0, len(createdRanges))
+	for _, r := range createdRanges {
+		facets = append(facets, DateRangeFacet{
+			Key:   r.Key,
+			From:  now.AddDate(0, 0, -r.Days),
+			Count: int64(counts[r.Key]),
+		})
+	}
+
+	return facets
+}
@@ -0,0 +1,99 @@
+package product
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FolderProductStore is the folder.ProductStore handed to the folder
+// service. Products deleted or moved by a folder delete are reported to the
+// product change listeners like any other product write, with the folder
+// transaction's ctx, so the search projection and domain events see them.
+type FolderProductStore struct {
+	productRepository ProductRepository
+	listeners         []ChangeListener
+}
+
+func NewFolderProductStore(productRepository ProductRepository, listeners ...ChangeListener) *FolderProductStore {
+	return &FolderProductStore{
+		productRepository: productRepository,
+		listeners:         listeners,
+	}
+}
+
+func (s *FolderProductStore) CountProductsByFolder(ctx context.Context, folderIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	return s.productRepository.CountProductsByFolder(ctx, folderIDs)
+}
+
+func (s *FolderProductStore) ProductImageKeysByFolders(ctx context.Context, folderIDs []primitive.ObjectID) ([]string, error) {
+	return s.productRepository.ProductImageKeysByFolders(ctx, folderIDs)
+}
+
+// DeleteProductsByFolders keeps the deleted products so listeners get their
+// last state, as they do for a single delete.
+func (s *FolderProductStore) DeleteProductsByFolders(ctx context.Context, folderIDs []primitive.ObjectID) (int64, error) {
+
+	products, err := s.productsInFolders(ctx, folderIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.productRepository.DeleteProductsByFolders(ctx, folderIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, product := range products {
+		change := ProductChange{ID: product.ID, Type: ChangeDeleted, Product: product}
+		if err := notifyListeners(ctx, s.listeners, change); err != nil {
+			return 0, err
+		}
+	}
+
+	return deleted, nil
+
+}
+
+func (s *FolderProductStore) MoveProductsToFolder(ctx context.Context, folderIDs []primitive.ObjectID, targetID primitive.ObjectID) (int64, error) {
+
+	products, err := s.productsInFolders(ctx, folderIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	moved, err := s.productRepository.MoveProductsToFolder(ctx, folderIDs, targetID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, product := range products {
+		change := ProductChange{ID: product.ID, Type: ChangeUpdated}
+		if err := notifyListeners(ctx, s.listeners, change); err != nil {
+			return 0, err
+		}
+	}
+
+	return moved, nil
+
+}
+
+func (s *FolderProductStore) productsInFolders(ctx context.Context, folderIDs []primitive.ObjectID) ([]*Product, error) {
+
+	var products []*Product
+
+	if len(s.listeners) == 0 || len(folderIDs) == 0 {
+		return products, nil
+	}
+
+	err := s.productRepository.IterateProducts(ctx, &ProductFilter{FolderIDs: folderIDs}, func(product *Product) error {
+		products = append(products, product)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+
+}
@@ -0,0 +1,200 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrImageNotFound = errors.New("image not found in product gallery")
+
+func addImage(images []ProductImage, req *AddImageRequest) ([]ProductImage, error) {
+
+	key := strings.TrimSpace(req.Key)
+	if key == "" {
+		return nil, errors.New("image key is required")
+	}
+
+	if indexOfImage(images, key) >= 0 {
+		return nil, fmt.Errorf("image %s is already in the gallery", key)
+	}
+
+	image := ProductImage{
+		Key:       key,
+		AltText:   req.AltText,
+		IsPrimary: req.IsPrimary || len(images) == 0,
+	}
+
+	images = append(images, image)
+
+	if image.IsPrimary {
+		setPrimary(images, key)
+	}
+
+	return images, nil
+}
+
+func updateImage(images []ProductImage, req *UpdateImageRequest) ([]ProductImage, error) {
+
+	index := indexOfImage(images, req.Key)
+	if index < 0 {
+		return nil, ErrImageNotFound
+	}
+
+	if req.AltText != nil {
+		images[index].AltText = *req.AltText
+	}
+
+	if req.IsPrimary != nil && *req.IsPrimary {
+		setPrimary(images, req.Key)
+	}
+
+	return images, nil
+}
+
+func removeImage(images []ProductImage, key string) ([]ProductImage, error) {
+
+	index := indexOfImage(images, key)
+	if index < 0 {
+		return nil, ErrImageNotFound
+	}
+
+	wasPrimary := images[index].IsPrimary
+	images = append(images[:index], images[index+1:]...)
+
+	if wasPrimary && len(images) > 0 {
+		images[0].IsPrimary = true
+	}
+
+	return images, nil
+}
+
+// reorderImages returns the gallery in the order given by keys, which must
+// name every image exactly once.
+func reorderImages(images []ProductImage, keys []string) ([]ProductImage, error) {
+
+	if len(keys) != len(images) {
+		return nil, errors.New("order must list every image in the gallery exactly once")
+	}
+
+	ordered := make([]ProductImage, 0, len(images))
+	seen := make(map[string]bool, len(keys))
+
+	for _, key := range keys {
+		index := indexOfImage(images, key)
+		if index < 0 || seen[key] {
+			return nil, errors.New("order must list every image in the gallery exactly once")
+		}
+		seen[key] = true
+		ordered = append(ordered, images[index])
+	}
+
+	return ordered, nil
+}
+
+func setPrimary(images []ProductImage, key string) {
+	for i := range images {
+		images[i].IsPrimary = images[i].Key == key
+	}
+}
+
+func indexOfImage(images []ProductImage, key string) int {
+	for i, image := range images {
+		if image.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func galleryImageKeys(images []ProductImage) []string {
+
+	keys := make([]string, 0, len(images))
+	for _, image := range images {
+		keys = append(keys, image.Key)
+	}
+
+	return keys
+}
+
+// productImageKeys returns every media key the product references: the cover,
+// the gallery and the variation option images.
+func productImageKeys(product *Product) map[string]struct{} {
+
+	keys := make(map[string]struct{})
+	if product == nil {
+		return keys
+	}
+
+	if product.CoverImage != "" {
+		keys[product.CoverImage] = struct{}{}
+	}
+	for _, key := range galleryImageKeys(product.Images) {
+		keys[key] = struct{}{}
+	}
+	for _, key := range variationImageKeys(product.Variations) {
+		keys[key] = struct{}{}
+	}
+
+	return keys
+}
+
+// ImageKeys returns the media keys the product references, sorted.
+func (p *Product) ImageKeys() []string {
+
+	keys := make([]string, 0)
+	for key := range productImageKeys(p) {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// releasedImageKeys lists the keys in before that the updated product no
+// longer references. A nil product releases everything.
+func releasedImageKeys(before map[string]struct{}, after *Product) []string {
+
+	still := productImageKeys(after)
+
+	var released []string
+	for key := range before {
+		if _, ok := still[key]; !ok {
+			released = append(released, key)
+		}
+	}
+
+	return released
+}
+
+func toImageResponses(images []ProductImage, urls map[string]string) []ProductImageResponse {
+
+	responses := make([]ProductImageResponse, 0, len(images))
+
+	for _, image := range images {
+		responses = append(responses, ProductImageResponse{
+			Key:       image.Key,
+			Url:       urls[image.Key],
+			AltText:   image.AltText,
+			IsPrimary: image.IsPrimary,
+		})
+	}
+
+	return responses
+}
+
+func validateVideoUrl(value string) error {
+
+	if value == "" {
+		return nil
+	}
+
+	u, err := url.ParseRequestURI(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid video url: %s", value)
+	}
+
+	return nil
+}
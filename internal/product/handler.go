@@ -3,11 +3,16 @@ package product
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"product-service/helper"
 	"product-service/pkg/constants"
+	"product-service/pkg/qr"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type ProductHandler struct {
@@ -47,10 +52,23 @@ func (h *ProductHandler) GetAllProducts(ctx *gin.Context) {
 		return
 	}
 
+	var req GetProductsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	req.Specs = specExpressions(ctx.Request.URL.Query())
+
 	c := context.WithValue(ctx, constants.TokenKey, token)
 
-	res, err := h.ProductService.GetAllProducts(c)
+	res, err := h.ProductService.GetAllProducts(c, &req)
 	if err != nil {
+		if isInvalidQuery(err) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
 		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
 		return
 	}
@@ -102,7 +120,15 @@ func (h *ProductHandler) UpdateProduct(ctx *gin.Context) {
 		return
 	}
 
-	err := h.ProductService.UpdateProduct(ctx, &req, id)
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err := h.ProductService.UpdateProduct(c, &req, id)
 	if err != nil {
 		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
 		return
@@ -121,7 +147,15 @@ func (h *ProductHandler) DeleteProduct(ctx *gin.Context) {
 		return
 	}
 
-	err := h.ProductService.DeleteProduct(ctx, id)
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err := h.ProductService.DeleteProduct(c, id)
 	if err != nil {
 		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
 		return
@@ -130,3 +164,555 @@ func (h *ProductHandler) DeleteProduct(ctx *gin.Context) {
 	helper.SendSuccess(ctx, http.StatusOK, "Product deleted successfully", nil)
 
 }
+
+func (h *ProductHandler) GetVariations(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	res, err := h.ProductService.GetVariations(c, id)
+	if err != nil {
+		sendVariationError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Variations retrieved successfully", res)
+
+}
+
+func (h *ProductHandler) CreateVariation(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req VariationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.ProductService.CreateVariation(ctx, &req, id)
+	if err != nil {
+		sendVariationError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Variation created successfully", res)
+
+}
+
+func (h *ProductHandler) UpdateVariation(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+	variationID := ctx.Param("variation_id")
+
+	if id == "" || variationID == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id and variation_id are required"), nil)
+		return
+	}
+
+	var req VariationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err := h.ProductService.UpdateVariation(c, &req, id, variationID)
+	if err != nil {
+		sendVariationError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Variation updated successfully", nil)
+
+}
+
+func (h *ProductHandler) DeleteVariation(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+	variationID := ctx.Param("variation_id")
+
+	if id == "" || variationID == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id and variation_id are required"), nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err := h.ProductService.DeleteVariation(c, id, variationID)
+	if err != nil {
+		sendVariationError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Variation deleted successfully", nil)
+
+}
+
+func sendVariationError(ctx *gin.Context, err error) {
+
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		helper.SendError(ctx, http.StatusNotFound, errors.New("product not found"), nil)
+	case errors.Is(err, ErrVariationNotFound):
+		helper.SendError(ctx, http.StatusNotFound, err, nil)
+	case errors.Is(err, ErrInvalidVariation):
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+	default:
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+	}
+}
+
+func (h *ProductHandler) GetSpecifications(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	res, err := h.ProductService.GetSpecifications(ctx, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Specifications retrieved successfully", res)
+
+}
+
+func (h *ProductHandler) UpdateSpecifications(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req UpdateSpecificationsRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.ProductService.UpdateSpecifications(ctx, &req, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Specifications updated successfully", nil)
+
+}
+
+func (h *ProductHandler) AddSpecification(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req SpecificationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.ProductService.AddSpecification(ctx, &req, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Specification added successfully", nil)
+
+}
+
+func (h *ProductHandler) DeleteSpecification(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+	attributeName := ctx.Param("attribute_name")
+
+	if id == "" || attributeName == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id and attribute_name are required"), nil)
+		return
+	}
+
+	err := h.ProductService.DeleteSpecification(ctx, id, attributeName)
+	if err != nil {
+		if errors.Is(err, ErrSpecificationNotFound) {
+			helper.SendError(ctx, http.StatusNotFound, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Specification deleted successfully", nil)
+
+}
+
+func (h *ProductHandler) GetImages(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	res, err := h.ProductService.GetImages(c, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Images retrieved successfully", res)
+
+}
+
+func (h *ProductHandler) AddImage(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req AddImageRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.ProductService.AddImage(ctx, &req, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Image added successfully", nil)
+
+}
+
+func (h *ProductHandler) UpdateImage(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req UpdateImageRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.ProductService.UpdateImage(ctx, &req, id)
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			helper.SendError(ctx, http.StatusNotFound, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Image updated successfully", nil)
+
+}
+
+func (h *ProductHandler) ReorderImages(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req ReorderImagesRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.ProductService.ReorderImages(ctx, &req, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Images reordered successfully", nil)
+
+}
+
+func (h *ProductHandler) RemoveImage(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+	key := ctx.Query("key")
+
+	if id == "" || key == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id and key are required"), nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err := h.ProductService.RemoveImage(c, id, key)
+	if err != nil {
+		if errors.Is(err, ErrImageNotFound) {
+			helper.SendError(ctx, http.StatusNotFound, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Image removed successfully", nil)
+
+}
+
+func (h *ProductHandler) GetQRCode(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req QRCodeRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	code, err := h.ProductService.GetQRCode(ctx, &req, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			helper.SendError(ctx, http.StatusNotFound, errors.New("product not found"), nil)
+			return
+		}
+		if errors.Is(err, qr.ErrInvalidOptions) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	if ctx.GetHeader("If-None-Match") == code.ETag {
+		ctx.Header("Cache-Control", "private, max-age=86400")
+		ctx.Header("ETag", code.ETag)
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
+	img, err := code.Render()
+	if err != nil {
+		if errors.Is(err, qr.ErrInvalidOptions) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	ctx.Header("Cache-Control", "private, max-age=86400")
+	ctx.Header("ETag", img.ETag)
+	ctx.Data(http.StatusOK, img.ContentType, img.Data)
+
+}
+
+func (h *ProductHandler) GenerateLabels(ctx *gin.Context) {
+
+	var req LabelRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	pdf, err := h.ProductService.GenerateLabels(ctx, &req)
+	if err != nil {
+		sendLabelError(ctx, err)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="labels.pdf"`)
+	ctx.Data(http.StatusOK, "application/pdf", pdf)
+
+}
+
+func sendLabelError(ctx *gin.Context, err error) {
+
+	switch {
+	case errors.Is(err, ErrUnknownLabelLayout), errors.Is(err, ErrInvalidLabelRequest):
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+	case errors.Is(err, ErrLabelProductNotFound):
+		helper.SendError(ctx, http.StatusNotFound, err, nil)
+	default:
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+	}
+}
+
+func (h *ProductHandler) GetLabelLayouts(ctx *gin.Context) {
+	helper.SendSuccess(ctx, http.StatusOK, "Label layouts retrieved successfully", LabelLayouts())
+}
+
+func (h *ProductHandler) ExportProducts(ctx *gin.Context) {
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	var req ExportProductsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	req.Specs = specExpressions(ctx.Request.URL.Query())
+
+	contentType, extension, err := ExportContentType(req.Format)
+	if err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	fileName := fmt.Sprintf("products-%s.%s", time.Now().Format("20060102"), extension)
+
+	ctx.Header("Content-Type", contentType)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	err = h.ProductService.ExportProducts(c, &req, ctx.Writer)
+	if err == nil {
+		return
+	}
+
+	// Once rows have been streamed the status line is gone and the
+	// truncated body is all the client will see.
+	if ctx.Writer.Written() {
+		log.Println("Error exporting products:", err)
+		ctx.Abort()
+		return
+	}
+
+	ctx.Writer.Header().Del("Content-Type")
+	ctx.Writer.Header().Del("Content-Disposition")
+
+	if isInvalidQuery(err) {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+	helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+
+}
+
+func (h *ProductHandler) SearchProducts(ctx *gin.Context) {
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	var req SearchProductsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	req.Specs = specExpressions(ctx.Request.URL.Query())
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	res, err := h.ProductService.SearchProducts(c, &req)
+	if err != nil {
+		if errors.Is(err, ErrEmptySearch) || errors.Is(err, ErrUnsupportedSearchFilter) || isInvalidQuery(err) {
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Products retrieved successfully", res)
+
+}
+
+// isInvalidQuery reports whether err comes from parsing the listing query
+// parameters shared by list, export and search.
+func isInvalidQuery(err error) bool {
+	return errors.Is(err, ErrInvalidCursor) ||
+		errors.Is(err, ErrInvalidSort) ||
+		errors.Is(err, ErrInvalidDate) ||
+		errors.Is(err, ErrInvalidFilter) ||
+		errors.Is(err, ErrInvalidSpecFilter)
+}
@@ -0,0 +1,266 @@
+package product
+
+import (
+	"context"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"product-service/pkg/constants"
+	"product-service/pkg/mongotx"
+	"product-service/pkg/qr"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeQRService struct {
+	ProductService
+	content string
+}
+
+func (s *fakeQRService) GetQRCode(ctx context.Context, req *QRCodeRequest, id string) (*qr.Code, error) {
+
+	opts := qr.DefaultOptions()
+	opts.Format = req.Format
+	opts.Size = req.Size
+
+	return qr.New(s.content, opts)
+}
+
+func serveQRCode(t *testing.T, target, ifNoneMatch string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	handler := NewProductHandler(&fakeQRService{content: "https://example.com/p/1"})
+	router.GET("/products/:id/qrcode", handler.GetQRCode)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestGetQRCodeConditional(t *testing.T) {
+
+	first := serveQRCode(t, "/products/1/qrcode?size=128", "")
+	if first.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", first.Code, first.Body)
+	}
+	if first.Header().Get("Content-Type") != "image/png" {
+		t.Errorf("Content-Type = %q, want image/png", first.Header().Get("Content-Type"))
+	}
+
+	img, err := png.Decode(first.Body)
+	if err != nil {
+		t.Fatalf("decoding PNG: %v", err)
+	}
+	if img.Bounds().Dx() != 128 {
+		t.Errorf("image width = %d, want 128", img.Bounds().Dx())
+	}
+
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag header")
+	}
+
+	again := serveQRCode(t, "/products/1/qrcode?size=128", etag)
+	if again.Code != http.StatusNotModified {
+		t.Fatalf("status = %d, want 304", again.Code)
+	}
+	if again.Body.Len() != 0 {
+		t.Errorf("304 response has a %d byte body", again.Body.Len())
+	}
+	if again.Header().Get("ETag") != etag {
+		t.Errorf("304 ETag = %q, want %q", again.Header().Get("ETag"), etag)
+	}
+
+	resized := serveQRCode(t, "/products/1/qrcode?size=256", etag)
+	if resized.Code != http.StatusOK {
+		t.Errorf("status for other options = %d, want 200", resized.Code)
+	}
+	if resized.Header().Get("ETag") == etag {
+		t.Error("ETag did not change with the size")
+	}
+}
+
+// serveProducts runs handler behind a route that sets the token the auth
+// middleware would, backed by a service whose stores are empty fakes.
+func serveProducts(t *testing.T, repository *fakeProductRepository, path string, handler func(h *ProductHandler) gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+
+	service := NewProductService(repository, &fakeEnricher{}, &fakeImageLifecycle{}, &fakeFolderRepository{}, nil, repository, mongotx.NewDirectRunner())
+	h := NewProductHandler(service)
+
+	router := gin.New()
+	router.Handle(method, path, func(ctx *gin.Context) {
+		ctx.Set(constants.Token, "token")
+	}, handler(h))
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	return res
+}
+
+func TestProductQueryErrors(t *testing.T) {
+
+	routes := []struct {
+		name    string
+		path    string
+		handler func(h *ProductHandler) gin.HandlerFunc
+		prefix  string
+	}{
+		{name: "list", path: "/products", handler: func(h *ProductHandler) gin.HandlerFunc { return h.GetAllProducts }, prefix: "/products?"},
+		{name: "export", path: "/products/export", handler: func(h *ProductHandler) gin.HandlerFunc { return h.ExportProducts }, prefix: "/products/export?format=csv&"},
+		{name: "search", path: "/products/search", handler: func(h *ProductHandler) gin.HandlerFunc { return h.SearchProducts }, prefix: "/products/search?q=lamp&"},
+	}
+
+	queries := []struct {
+		name       string
+		query      string
+		listOnly   bool
+		wantStatus int
+	}{
+		{name: "valid", query: "sort=product_name:desc&created_from=2026-01-01", wantStatus: http.StatusOK},
+		{name: "malformed cursor", query: "cursor=!!!", listOnly: true, wantStatus: http.StatusBadRequest},
+		{name: "cursor for another sort", query: "cursor=eyJmIjoidXBkYXRlZF9hdCJ9", listOnly: true, wantStatus: http.StatusBadRequest},
+		{name: "unsupported sort field", query: "sort=qrcode", listOnly: true, wantStatus: http.StatusBadRequest},
+		{name: "unsupported sort direction", query: "sort=created_at:up", listOnly: true, wantStatus: http.StatusBadRequest},
+		{name: "invalid date", query: "created_from=yesterday", wantStatus: http.StatusBadRequest},
+		{name: "invalid folder id", query: "folder_id=nope", wantStatus: http.StatusBadRequest},
+		{name: "invalid topic id", query: "topic_id=nope", wantStatus: http.StatusBadRequest},
+		{name: "non-numeric spec bound", query: "spec.weight%3C=abc", wantStatus: http.StatusBadRequest},
+		{name: "malformed spec quantity", query: "spec.weight%3E=1.2.3kg", wantStatus: http.StatusBadRequest},
+		{name: "spec filter without operator", query: "spec.weight", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, route := range routes {
+		for _, tt := range queries {
+			// Search ranks by relevance and pages by offset, so it ignores
+			// sort and cursor.
+			if tt.listOnly && route.name == "search" {
+				continue
+			}
+
+			t.Run(route.name+" "+tt.name, func(t *testing.T) {
+
+				res := serveProducts(t, &fakeProductRepository{}, route.path, route.handler, http.MethodGet, route.prefix+tt.query, "")
+				if res.Code != tt.wantStatus {
+					t.Errorf("status = %d, want %d: %s", res.Code, tt.wantStatus, res.Body)
+				}
+			})
+		}
+	}
+}
+
+func TestVariationErrors(t *testing.T) {
+
+	colourID := primitive.NewObjectID()
+	sizeID := primitive.NewObjectID()
+	product := &Product{
+		ID: primitive.NewObjectID(),
+		Variations: []Variation{
+			{ID: colourID, VariationName: "Colour", Options: []VariationOption{{ID: primitive.NewObjectID(), Option: "Red"}}},
+			{ID: sizeID, VariationName: "Size", Options: []VariationOption{{ID: primitive.NewObjectID(), Option: "L"}}},
+		},
+	}
+
+	create := func(h *ProductHandler) gin.HandlerFunc { return h.CreateVariation }
+	update := func(h *ProductHandler) gin.HandlerFunc { return h.UpdateVariation }
+	remove := func(h *ProductHandler) gin.HandlerFunc { return h.DeleteVariation }
+
+	base := "/products/" + product.ID.Hex() + "/variations"
+	missingProduct := "/products/" + primitive.NewObjectID().Hex() + "/variations"
+
+	tests := []struct {
+		name       string
+		handler    func(h *ProductHandler) gin.HandlerFunc
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "create", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material","options":[{"option":"Oak"}]}`, wantStatus: http.StatusOK},
+		{name: "create without name", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":" ","options":[{"option":"Oak"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create without options", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material"}`, wantStatus: http.StatusBadRequest},
+		{name: "create with duplicate option", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material","options":[{"option":"Oak"},{"option":"oak"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create with negative price", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material","options":[{"option":"Oak","price":-1}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create with negative stock", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material","options":[{"option":"Oak","stock":-1}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create with malformed option id", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"Material","options":[{"id":"x","option":"Oak"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create duplicate variation", handler: create, method: http.MethodPost, target: base, body: `{"variation_name":"colour","options":[{"option":"Blue"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "create on missing product", handler: create, method: http.MethodPost, target: missingProduct, body: `{"variation_name":"Material","options":[{"option":"Oak"}]}`, wantStatus: http.StatusNotFound},
+		{name: "update", handler: update, method: http.MethodPut, target: base + "/" + colourID.Hex(), body: `{"variation_name":"Colour","options":[{"option":"Blue"}]}`, wantStatus: http.StatusOK},
+		{name: "update to a duplicate name", handler: update, method: http.MethodPut, target: base + "/" + colourID.Hex(), body: `{"variation_name":"Size","options":[{"option":"Blue"}]}`, wantStatus: http.StatusBadRequest},
+		{name: "update unknown variation", handler: update, method: http.MethodPut, target: base + "/" + primitive.NewObjectID().Hex(), body: `{"variation_name":"Colour","options":[{"option":"Blue"}]}`, wantStatus: http.StatusNotFound},
+		{name: "update malformed variation id", handler: update, method: http.MethodPut, target: base + "/nope", body: `{"variation_name":"Colour","options":[{"option":"Blue"}]}`, wantStatus: http.StatusNotFound},
+		{name: "delete", handler: remove, method: http.MethodDelete, target: base + "/" + sizeID.Hex(), wantStatus: http.StatusOK},
+		{name: "delete unknown variation", handler: remove, method: http.MethodDelete, target: base + "/" + primitive.NewObjectID().Hex(), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			path := "/products/:id/variations"
+			if tt.method != http.MethodPost {
+				path += "/:variation_id"
+			}
+
+			repository := &fakeProductRepository{products: []*Product{product}}
+
+			res := serveProducts(t, repository, path, tt.handler, tt.method, tt.target, tt.body)
+			if res.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", res.Code, tt.wantStatus, res.Body)
+			}
+		})
+	}
+}
+
+func TestLabelErrors(t *testing.T) {
+
+	product := &Product{ID: primitive.NewObjectID(), ProductName: "Lamp", QRCode: "https://example.com/p/1"}
+	ids := `"product_ids":["` + product.ID.Hex() + `"]`
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "products", body: `{` + ids + `}`, wantStatus: http.StatusOK},
+		{name: "neither products nor folder", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "both products and folder", body: `{` + ids + `,"folder_id":"` + primitive.NewObjectID().Hex() + `"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown layout", body: `{` + ids + `,"layout":"nope"}`, wantStatus: http.StatusBadRequest},
+		{name: "negative copies", body: `{` + ids + `,"copies":-1}`, wantStatus: http.StatusBadRequest},
+		{name: "too many copies", body: `{` + ids + `,"copies":101}`, wantStatus: http.StatusBadRequest},
+		{name: "too many labels", body: `{"product_ids":["` + strings.Repeat(product.ID.Hex()+`","`, 10) + product.ID.Hex() + `"],"copies":100}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed product id", body: `{"product_ids":["nope"]}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed folder id", body: `{"folder_id":"nope"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing product", body: `{"product_ids":["` + primitive.NewObjectID().Hex() + `"]}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			repository := &fakeProductRepository{products: []*Product{product}}
+			labels := func(h *ProductHandler) gin.HandlerFunc { return h.GenerateLabels }
+
+			res := serveProducts(t, repository, "/products/labels", labels, http.MethodPost, "/products/labels", tt.body)
+			if res.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", res.Code, tt.wantStatus, res.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package product
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"product-service/pkg/qr"
+	"product-service/pkg/text"
+	"strconv"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	defaultLabelLayout = "avery-l7160"
+	maxLabels          = 1000
+	labelPadding       = 2.0
+)
+
+var (
+	ErrUnknownLabelLayout   = errors.New("unknown label layout")
+	ErrInvalidLabelRequest  = errors.New("invalid label request")
+	ErrLabelProductNotFound = errors.New("label product not found")
+)
+
+// LabelLayout describes a sheet of equally sized labels. All lengths are in
+// millimetres.
+type LabelLayout struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	PageSize    string  `json:"page_size"`
+	Columns     int     `json:"columns"`
+	Rows        int     `json:"rows"`
+	LabelWidth  float64 `json:"label_width"`
+	LabelHeight float64 `json:"label_height"`
+	MarginTop   float64 `json:"margin_top"`
+	MarginLeft  float64 `json:"margin_left"`
+	GapX        float64 `json:"gap_x"`
+	GapY        float64 `json:"gap_y"`
+}
+
+var labelLayouts = []LabelLayout{
+	{Name: "avery-l7160", Description: "A4, 21 labels 63.5 x 38.1 mm", PageSize: "A4", Columns: 3, Rows: 7, LabelWidth: 63.5, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 7.2, GapX: 2.5},
+	{Name: "avery-l7159", Description: "A4, 24 labels 63.5 x 33.9 mm", PageSize: "A4", Columns: 3, Rows: 8, LabelWidth: 63.5, LabelHeight: 33.9, MarginTop: 12.9, MarginLeft: 6.5, GapX: 2.5},
+	{Name: "avery-l7163", Description: "A4, 14 labels 99.1 x 38.1 mm", PageSize: "A4", Columns: 2, Rows: 7, LabelWidth: 99.1, LabelHeight: 38.1, MarginTop: 15.15, MarginLeft: 4.65, GapX: 2.5},
+	{Name: "avery-5160", Description: "Letter, 30 labels 66.7 x 25.4 mm", PageSize: "Letter", Columns: 3, Rows: 10, LabelWidth: 66.7, LabelHeight: 25.4, MarginTop: 12.7, MarginLeft: 4.7, GapX: 3.2},
+	{Name: "avery-5163", Description: "Letter, 10 labels 101.6 x 50.8 mm", PageSize: "Letter", Columns: 2, Rows: 5, LabelWidth: 101.6, LabelHeight: 50.8, MarginTop: 12.7, MarginLeft: 3.95, GapX: 4.8},
+}
+
+func LabelLayouts() []LabelLayout {
+	return labelLayouts
+}
+
+func findLabelLayout(name string) (*LabelLayout, error) {
+
+	if name == "" {
+		name = defaultLabelLayout
+	}
+
+	for i := range labelLayouts {
+		if labelLayouts[i].Name == name {
+			return &labelLayouts[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownLabelLayout, name)
+}
+
+// renderLabels draws one label per product, filling each sheet row by row.
+// Text is folded to Latin characters because the built-in PDF fonts have no
+// glyphs for Vietnamese diacritics.
+func renderLabels(layout *LabelLayout, products []*Product, copies int) ([]byte, error) {
+
+	pdf := fpdf.New("P", "mm", layout.PageSize, "")
+	pdf.SetMargins(0, 0, 0)
+	pdf.SetAutoPageBreak(false, 0)
+
+	perPage := layout.Columns * layout.Rows
+	position := 0
+
+	for _, product := range products {
+
+		image, err := registerQRImage(pdf, product)
+		if err != nil {
+			return nil, err
+		}
+
+		for c := 0; c < copies; c++ {
+
+			if position%perPage == 0 {
+				pdf.AddPage()
+			}
+
+			slot := position % perPage
+			x := layout.MarginLeft + float64(slot%layout.Columns)*(layout.LabelWidth+layout.GapX)
+			y := layout.MarginTop + float64(slot/layout.Columns)*(layout.LabelHeight+layout.GapY)
+
+			if err := drawLabel(pdf, layout, product, image, x, y); err != nil {
+				return nil, err
+			}
+
+			position++
+		}
+	}
+
+	if position == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// registerQRImage encodes the product's QR code once and registers it with
+// the document, so every copy of the label reuses the same image.
+func registerQRImage(pdf *fpdf.Fpdf, product *Product) (string, error) {
+
+	content := product.QRCode
+	if content == "" {
+		content = qr.ProductPayload(product.ID.Hex())
+	}
+
+	img, err := qr.Encode(content, qr.Options{Format: qr.FormatPNG, Size: 512, ECC: "M", Margin: 1})
+	if err != nil {
+		return "", err
+	}
+
+	name := "qr-" + product.ID.Hex()
+	pdf.RegisterImageOptionsReader(name, fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(img.Data))
+
+	return name, pdf.Error()
+}
+
+func drawLabel(pdf *fpdf.Fpdf, layout *LabelLayout, product *Product, image string, x, y float64) error {
+
+	side := layout.LabelHeight - 2*labelPadding
+	pdf.ImageOptions(image, x+labelPadding, y+labelPadding, side, side, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	textX := x + side + 2*labelPadding
+	textWidth := layout.LabelWidth - side - 3*labelPadding
+
+	fontSize := math.Min(10, layout.LabelHeight/4)
+	lineHeight := fontSize * 0.45
+
+	pdf.SetFont("Helvetica", "B", fontSize)
+
+	lines := pdf.SplitText(text.Fold(product.ProductName), textWidth)
+	if len(lines) > 2 {
+		lines = lines[:2]
+		lines[1] = strings.TrimSpace(lines[1]) + "..."
+	}
+
+	cursor := y + labelPadding
+	for _, line := range lines {
+		pdf.SetXY(textX, cursor)
+		pdf.CellFormat(textWidth, lineHeight, line, "", 0, "L", false, 0, "")
+		cursor += lineHeight
+	}
+
+	pdf.SetFont("Helvetica", "", fontSize*0.85)
+	cursor += lineHeight * 0.5
+
+	for _, line := range []string{
+		"Store: " + formatPrice(product.OriginPriceStore),
+		"Service: " + formatPrice(product.OriginPriceService),
+	} {
+		pdf.SetXY(textX, cursor)
+		pdf.CellFormat(textWidth, lineHeight, line, "", 0, "L", false, 0, "")
+		cursor += lineHeight
+	}
+
+	return pdf.Error()
+}
+
+// formatPrice groups thousands and drops a zero fraction: 120000 becomes
+// "120,000" and 12.5 becomes "12.50".
+func formatPrice(price float64) string {
+
+	whole := int64(math.Abs(price))
+	cents := int64(math.Round((math.Abs(price) - float64(whole)) * 100))
+	if cents == 100 {
+		whole++
+		cents = 0
+	}
+
+	digits := strconv.FormatInt(whole, 10)
+
+	var b strings.Builder
+	if price < 0 {
+		b.WriteByte('-')
+	}
+
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+
+	if cents > 0 {
+		fmt.Fprintf(&b, ".%02d", cents)
+	}
+
+	return b.String()
+}
@@ -16,7 +16,58 @@ type Product struct {
 	TopicID            primitive.ObjectID `json:"topic_id" bson:"topic_id"`
 	FolderID           primitive.ObjectID `json:"folder_id" bson:"folder_id"`
 	QRCode             string             `json:"qrcode" bson:"qrcode"`
+	Variations         []Variation        `json:"variations" bson:"variations"`
+	Specifications     []Specification    `json:"specifications" bson:"specifications"`
+	UsageConfig        *UsageConfig       `json:"usage_config" bson:"usage_config"`
+	Images             []ProductImage     `json:"images" bson:"images"`
+	VideoUrl           string             `json:"video_url" bson:"video_url"`
+	Search             *ProductSearch     `json:"-" bson:"search,omitempty"`
 	CreatedAt          time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
 }
-	
\ No newline at end of file
+
+// ProductSearch holds accent-folded copies of the searchable fields. The
+// text index covers these rather than the originals so that "do choi" finds
+// "Đồ chơi".
+type ProductSearch struct {
+	Name        string `json:"name" bson:"name"`
+	Description string `json:"description" bson:"description"`
+}
+
+type Variation struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id"`
+	VariationName string             `json:"variation_name" bson:"variation_name"`
+	Options       []VariationOption  `json:"options" bson:"options"`
+}
+
+type VariationOption struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Option string             `json:"option" bson:"option"`
+	Price  *float64           `json:"price" bson:"price"`
+	Stock  int                `json:"stock" bson:"stock"`
+	Image  string             `json:"image" bson:"image"`
+}
+
+type Specification struct {
+	AttributeName   string      `json:"attribute_name" bson:"attribute_name"`
+	Type            string      `json:"type" bson:"type"`
+	Value           interface{} `json:"value" bson:"value"`
+	Unit            string      `json:"unit" bson:"unit"`
+	Options         []string    `json:"options,omitempty" bson:"options,omitempty"`
+	NormalizedValue *float64    `json:"-" bson:"normalized_value,omitempty"`
+	BaseUnit        string      `json:"-" bson:"base_unit,omitempty"`
+}
+
+// UsageConfig describes a product consumed as a service. Durations are in
+// minutes; zero NumberOfUses or MaximumUsageTime means no limit.
+type UsageConfig struct {
+	NumberOfUses     int `json:"number_of_uses" bson:"number_of_uses"`
+	MinimumUsageTime int `json:"minimum_usage_time" bson:"minimum_usage_time"`
+	MaximumUsageTime int `json:"maximum_usage_time" bson:"maximum_usage_time"`
+}
+
+type ProductImage struct {
+	Key       string `json:"key" bson:"key"`
+	AltText   string `json:"alt_text" bson:"alt_text"`
+	IsPrimary bool   `json:"is_primary" bson:"is_primary"`
+}
@@ -0,0 +1,185 @@
+package product
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize  = 20
+	maxPageSize      = 200
+	defaultSortField = "created_at"
+)
+
+var sortableFields = map[string]bool{
+	"product_name":           true,
+	"original_price_store":   true,
+	"original_price_service": true,
+	"created_at":             true,
+	"updated_at":             true,
+}
+
+var (
+	ErrInvalidCursor = errors.New("invalid cursor")
+	ErrInvalidSort   = errors.New("invalid sort")
+	ErrInvalidDate   = errors.New("invalid date")
+	ErrInvalidFilter = errors.New("invalid filter")
+)
+
+type pageCursor struct {
+	Field string      `json:"f"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+type cursorPosition struct {
+	Field string
+	Value interface{}
+	ID    primitive.ObjectID
+}
+
+func parseSort(sort string) (string, bool, error) {
+
+	if sort == "" {
+		return defaultSortField, true, nil
+	}
+
+	field, direction, found := strings.Cut(sort, ":")
+	if !sortableFields[field] {
+		return "", false, fmt.Errorf("%w: unsupported sort field: %s", ErrInvalidSort, field)
+	}
+
+	if !found || direction == "asc" {
+		return field, false, nil
+	}
+
+	if direction != "desc" {
+		return "", false, fmt.Errorf("%w: unsupported sort direction: %s", ErrInvalidSort, direction)
+	}
+
+	return field, true, nil
+}
+
+func sortValue(product *Product, field string) interface{} {
+	switch field {
+	case "product_name":
+		return product.ProductName
+	case "original_price_store":
+		return product.OriginPriceStore
+	case "original_price_service":
+		return product.OriginPriceService
+	case "updated_at":
+		return product.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	default:
+		return product.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
+
+func encodeCursor(product *Product, field string) string {
+
+	data, err := json.Marshal(pageCursor{
+		Field: field,
+		Value: sortValue(product, field),
+		ID:    product.ID.Hex(),
+	})
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(cursor string, field string) (*cursorPosition, error) {
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c pageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.Field != field {
+		return nil, fmt.Errorf("%w: cursor was issued for sort field %s", ErrInvalidCursor, c.Field)
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	position := &cursorPosition{Field: c.Field, ID: id}
+
+	switch field {
+	case "product_name":
+		value, ok := c.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		position.Value = value
+	case "original_price_store", "original_price_service":
+		value, ok := c.Value.(float64)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		position.Value = value
+	default:
+		raw, ok := c.Value.(string)
+		if !ok {
+			return nil, ErrInvalidCursor
+		}
+		value, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		position.Value = value
+	}
+
+	return position, nil
+}
+
+func (c *cursorPosition) filter(desc bool) bson.M {
+
+	op := "$gt"
+	if desc {
+		op = "$lt"
+	}
+
+	return bson.M{
+		"$or": bson.A{
+			bson.M{c.Field: bson.M{op: c.Value}},
+			bson.M{c.Field: c.Value, "_id": bson.M{op: c.ID}},
+		},
+	}
+}
+
+func parseDateBound(value string, endOfDay bool) (*time.Time, error) {
+
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDate, value)
+	}
+
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	return &t, nil
+}
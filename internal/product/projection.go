@@ -0,0 +1,530 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"html"
+	"log"
+	"product-service/internal/folder"
+	"product-service/internal/shared/ports"
+	"product-service/pkg/constants"
+	"product-service/pkg/elastic"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	projectionQueueSize     = 4096
+	projectionBatchSize     = 200
+	projectionFlushInterval = time.Second
+)
+
+var ErrUnsupportedSearchFilter = errors.New("specification filters are not supported by the search engine")
+
+// productIndex folds case and diacritics on both indexing and query side,
+// so Vietnamese text matches with or without accents.
+var productIndex = map[string]interface{}{
+	"settings": map[string]interface{}{
+		"analysis": map[string]interface{}{
+			"analyzer": map[string]interface{}{
+				"folded": map[string]interface{}{
+					"type":      "custom",
+					"tokenizer": "standard",
+					"filter":    []string{"lowercase", "asciifolding"},
+				},
+			},
+		},
+	},
+	"mappings": map[string]interface{}{
+		"properties": map[string]interface{}{
+			"product_name":            foldedTextField(),
+			"product_description":     map[string]interface{}{"type": "text", "analyzer": "folded"},
+			"folder_id":               map[string]interface{}{"type": "keyword"},
+			"folder_ids":              map[string]interface{}{"type": "keyword"},
+			"folder_path":             foldedTextField(),
+			"topic_id":                map[string]interface{}{"type": "keyword"},
+			"topic_name":              foldedTextField(),
+			"original_price_store":    map[string]interface{}{"type": "double"},
+			"original_price_service":  map[string]interface{}{"type": "double"},
+			"effective_price_store":   map[string]interface{}{"type": "double"},
+			"effective_price_service": map[string]interface{}{"type": "double"},
+			"qrcode":                  map[string]interface{}{"type": "keyword"},
+			"cover_image":             map[string]interface{}{"type": "keyword", "index": false},
+			"created_at":              map[string]interface{}{"type": "date"},
+			"updated_at":              map[string]interface{}{"type": "date"},
+			"indexed_at":              map[string]interface{}{"type": "date"},
+		},
+	},
+}
+
+func foldedTextField() map[string]interface{} {
+	return map[string]interface{}{
+		"type":     "text",
+		"analyzer": "folded",
+		"fields": map[string]interface{}{
+			"keyword": map[string]interface{}{"type": "keyword", "ignore_above": 256},
+		},
+	}
+}
+
+type productDocument struct {
+	ProductName           string    `json:"product_name"`
+	ProductDescription    string    `json:"product_description"`
+	FolderID              string    `json:"folder_id"`
+	FolderIDs             []string  `json:"folder_ids"`
+	FolderPath            string    `json:"folder_path"`
+	TopicID               string    `json:"topic_id"`
+	TopicName             string    `json:"topic_name"`
+	OriginPriceStore      float64   `json:"original_price_store"`
+	OriginPriceService    float64   `json:"original_price_service"`
+	EffectivePriceStore   float64   `json:"effective_price_store"`
+	EffectivePriceService float64   `json:"effective_price_service"`
+	QRCode                string    `json:"qrcode"`
+	CoverImage            string    `json:"cover_image"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+	IndexedAt             time.Time `json:"indexed_at"`
+}
+
+// ElasticProjection keeps an Elasticsearch index of enriched products and
+// serves product search from it. Product writes and folder renames, moves
+// and reparenting deletes arrive as change notifications and are indexed in
+// batches by Run; a full Reindex picks up what notifications cannot see,
+// such as promotions starting or ending.
+type ElasticProjection struct {
+	client            *elastic.Client
+	index             string
+	productRepository ProductRepository
+	folderRepository  ports.FolderRepository
+	enricher          ProductEnricher
+	serviceToken      string
+	reindexInterval   time.Duration
+	changes           chan primitive.ObjectID
+	folderChanges     chan primitive.ObjectID
+}
+
+func NewElasticProjection(client *elastic.Client, index string, productRepository ProductRepository, folderRepository ports.FolderRepository, enricher ProductEnricher, serviceToken string, reindexInterval time.Duration) *ElasticProjection {
+	return &ElasticProjection{
+		client:            client,
+		index:             index,
+		productRepository: productRepository,
+		folderRepository:  folderRepository,
+		enricher:          enricher,
+		serviceToken:      serviceToken,
+		reindexInterval:   reindexInterval,
+		changes:           make(chan primitive.ObjectID, projectionQueueSize),
+		folderChanges:     make(chan primitive.ObjectID, projectionQueueSize),
+	}
+}
+
+// EnsureIndex creates the index with its mapping when it does not exist.
+func (p *ElasticProjection) EnsureIndex(ctx context.Context) error {
+
+	exists, err := p.client.IndexExists(ctx, p.index)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return nil
+	}
+
+	return p.client.CreateIndex(ctx, p.index, productIndex)
+}
+
+// ProductChanged queues the product for indexing. It never fails the
+// write: when the queue is full the change is dropped and left for the next
+// full reindex. The product is read back from Mongo at the next flush, once
+// the write has committed.
+func (p *ElasticProjection) ProductChanged(ctx context.Context, change ProductChange) error {
+
+	select {
+	case p.changes <- change.ID:
+	default:
+		log.Printf("%s queue full, dropping change of product %s", constants.ElasticProjection, change.ID.Hex())
+	}
+
+	return nil
+}
+
+// FolderChanged queues the folders whose products carry a stale folder
+// path: a renamed or moved folder, and the target of a reparenting delete,
+// which receives the deleted folder's children and products. Products
+// deleted with a folder are reported through ProductChanged.
+func (p *ElasticProjection) FolderChanged(ctx context.Context, change folder.FolderChange) error {
+
+	var id primitive.ObjectID
+
+	switch {
+	case change.Type == folder.ChangeRenamed, change.Type == folder.ChangeMoved:
+		id = change.ID
+	case change.Type == folder.ChangeDeleted && change.TargetID != nil:
+		id = *change.TargetID
+	default:
+		return nil
+	}
+
+	select {
+	case p.folderChanges <- id:
+	default:
+		log.Printf("%s queue full, dropping change of folder %s", constants.ElasticProjection, id.Hex())
+	}
+
+	return nil
+}
+
+// Run indexes queued changes until ctx is cancelled and, when a reindex
+// interval is configured, reindexes the whole catalog on that schedule.
+func (p *ElasticProjection) Run(ctx context.Context) {
+
+	ticker := time.NewTicker(projectionFlushInterval)
+	defer ticker.Stop()
+
+	var reindex <-chan time.Time
+	if p.reindexInterval > 0 {
+		reindexTicker := time.NewTicker(p.reindexInterval)
+		defer reindexTicker.Stop()
+		reindex = reindexTicker.C
+	}
+
+	pending := make(map[primitive.ObjectID]struct{})
+	pendingFolders := make(map[primitive.ObjectID]struct{})
+
+	flush := func() {
+		for id := range pendingFolders {
+			if err := p.syncFolder(ctx, id); err != nil {
+				log.Printf("%s error indexing products of folder %s: %v", constants.ElasticProjection, id.Hex(), err)
+			}
+		}
+		pendingFolders = make(map[primitive.ObjectID]struct{})
+
+		if len(pending) == 0 {
+			return
+		}
+
+		ids := make([]primitive.ObjectID, 0, len(pending))
+		for id := range pending {
+			ids = append(ids, id)
+		}
+		pending = make(map[primitive.ObjectID]struct{})
+
+		if err := p.Sync(ctx, ids); err != nil {
+			log.Printf("%s error indexing %d products: %v", constants.ElasticProjection, len(ids), err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-p.changes:
+			pending[id] = struct{}{}
+			if len(pending) >= projectionBatchSize {
+				flush()
+			}
+		case id := <-p.folderChanges:
+			pendingFolders[id] = struct{}{}
+		case <-ticker.C:
+			flush()
+		case <-reindex:
+			count, err := p.Reindex(ctx)
+			if err != nil {
+				log.Printf("%s scheduled reindex failed: %v", constants.ElasticProjection, err)
+				continue
+			}
+			log.Printf("%s reindexed %d products", constants.ElasticProjection, count)
+		}
+	}
+}
+
+// Sync indexes the current state of ids and removes the ones that no longer
+// exist. Commands that write products without running the projection call
+// it directly.
+func (p *ElasticProjection) Sync(ctx context.Context, ids []primitive.ObjectID) error {
+
+	products, err := p.productRepository.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	actions := p.indexActions(ctx, products, time.Now())
+
+	found := make(map[primitive.ObjectID]bool, len(products))
+	for _, product := range products {
+		found[product.ID] = true
+	}
+
+	for _, id := range ids {
+		if !found[id] {
+			actions = append(actions, elastic.BulkAction{Delete: true, ID: id.Hex()})
+		}
+	}
+
+	return p.client.Bulk(ctx, p.index, actions)
+}
+
+// syncFolder reindexes every product in folderID and its descendants.
+func (p *ElasticProjection) syncFolder(ctx context.Context, folderID primitive.ObjectID) error {
+
+	descendants, err := p.folderRepository.GetDescendantIDs(ctx, folderID)
+	if err != nil {
+		return err
+	}
+
+	filter := &ProductFilter{FolderIDs: append(descendants, folderID)}
+
+	_, err = p.indexProducts(ctx, filter, time.Now())
+
+	return err
+}
+
+// Reindex streams the whole catalog into the index, then deletes documents
+// that were not written during this run, which belong to products removed
+// behind the service's back. It returns the number of products indexed.
+func (p *ElasticProjection) Reindex(ctx context.Context) (int, error) {
+
+	if err := p.EnsureIndex(ctx); err != nil {
+		return 0, err
+	}
+
+	started := time.Now()
+
+	count, err := p.indexProducts(ctx, &ProductFilter{}, started)
+	if err != nil {
+		return count, err
+	}
+
+	_, err = p.client.DeleteByQuery(ctx, p.index, map[string]interface{}{
+		"range": map[string]interface{}{
+			"indexed_at": map[string]interface{}{"lt": started},
+		},
+	})
+	if err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// indexProducts streams the products matching filter into the index in
+// batches and returns how many were indexed.
+func (p *ElasticProjection) indexProducts(ctx context.Context, filter *ProductFilter, started time.Time) (int, error) {
+
+	count := 0
+	batch := make([]*Product, 0, projectionBatchSize)
+
+	flush := func() error {
+		err := p.client.Bulk(ctx, p.index, p.indexActions(ctx, batch, time.Now()))
+		if err != nil {
+			return err
+		}
+		count += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	err := p.productRepository.IterateProducts(ctx, filter, func(product *Product) error {
+		batch = append(batch, product)
+		if len(batch) < projectionBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+
+	return count, err
+}
+
+func (p *ElasticProjection) indexActions(ctx context.Context, products []*Product, indexedAt time.Time) []elastic.BulkAction {
+
+	if len(products) == 0 {
+		return nil
+	}
+
+	// Topic and image lookups run outside any user request.
+	callCtx := context.WithValue(ctx, constants.TokenKey, p.serviceToken)
+	responses := p.enricher.Enrich(callCtx, products)
+
+	actions := make([]elastic.BulkAction, 0, len(products))
+	for i, product := range products {
+		actions = append(actions, elastic.BulkAction{
+			ID:  product.ID.Hex(),
+			Doc: toProductDocument(product, responses[i], indexedAt),
+		})
+	}
+
+	return actions
+}
+
+func toProductDocument(product *Product, res *ProductResponse, indexedAt time.Time) *productDocument {
+
+	doc := &productDocument{
+		ProductName:           product.ProductName,
+		ProductDescription:    product.ProductDescription,
+		FolderID:              product.FolderID.Hex(),
+		TopicID:               product.TopicID.Hex(),
+		OriginPriceStore:      product.OriginPriceStore,
+		OriginPriceService:    product.OriginPriceService,
+		EffectivePriceStore:   res.EffectivePrice.PriceStore,
+		EffectivePriceService: res.EffectivePrice.PriceService,
+		QRCode:                product.QRCode,
+		CoverImage:            res.CoverImage,
+		CreatedAt:             product.CreatedAt,
+		UpdatedAt:             product.UpdatedAt,
+		IndexedAt:             indexedAt,
+	}
+
+	names := make([]string, 0, len(res.Folder.Breadcrumb))
+	for _, crumb := range res.Folder.Breadcrumb {
+		doc.FolderIDs = append(doc.FolderIDs, crumb.ID)
+		names = append(names, crumb.Name)
+	}
+	doc.FolderPath = strings.Join(names, " > ")
+
+	if res.Topic != nil {
+		doc.TopicName = res.Topic.Name
+	}
+
+	return doc
+}
+
+// SearchProducts ranks products in Elasticsearch and loads the hits from
+// Mongo, so responses carry the same data as the Mongo backend. Hits whose
+// product has been deleted since it was indexed are skipped.
+func (p *ElasticProjection) SearchProducts(ctx context.Context, query *SearchQuery) ([]*SearchHit, int64, error) {
+
+	if len(query.Filter.Specs) > 0 {
+		return nil, 0, ErrUnsupportedSearchFilter
+	}
+
+	body := map[string]interface{}{
+		"from":             query.Skip,
+		"size":             query.Limit,
+		"track_total_hits": true,
+		"_source":          false,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  query.Text,
+						"fields": []string{"product_name^10", "product_description"},
+					},
+				},
+				"filter": elasticFilters(&query.Filter),
+			},
+		},
+		"highlight": map[string]interface{}{
+			"encoder":   "html",
+			"pre_tags":  []string{highlightOpen},
+			"post_tags": []string{highlightClose},
+			"fields": map[string]interface{}{
+				"product_name": map[string]interface{}{"number_of_fragments": 0},
+				"product_description": map[string]interface{}{
+					"fragment_size":       snippetLength,
+					"number_of_fragments": 1,
+					"no_match_size":       snippetLength,
+				},
+			},
+		},
+	}
+
+	res, err := p.client.Search(ctx, p.index, body)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(res.Hits.Hits))
+	for _, hit := range res.Hits.Hits {
+		id, err := primitive.ObjectIDFromHex(hit.ID)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	products, err := p.productRepository.GetProductsByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	byID := make(map[string]*Product, len(products))
+	for _, product := range products {
+		byID[product.ID.Hex()] = product
+	}
+
+	hits := make([]*SearchHit, 0, len(products))
+	for _, hit := range res.Hits.Hits {
+		product, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+
+		highlights := &SearchHighlights{
+			ProductName:        html.EscapeString(product.ProductName),
+			ProductDescription: snippet(product.ProductDescription, nil),
+		}
+		if fragments := hit.Highlight["product_name"]; len(fragments) > 0 {
+			highlights.ProductName = fragments[0]
+		}
+		if fragments := hit.Highlight["product_description"]; len(fragments) > 0 {
+			highlights.ProductDescription = fragments[0]
+		}
+
+		hits = append(hits, &SearchHit{
+			Product:    *product,
+			Score:      hit.Score,
+			Highlights: highlights,
+		})
+	}
+
+	return hits, res.Hits.Total.Value, nil
+}
+
+func elasticFilters(f *ProductFilter) []interface{} {
+
+	filters := []interface{}{}
+
+	if len(f.FolderIDs) > 0 {
+		ids := make([]string, 0, len(f.FolderIDs))
+		for _, id := range f.FolderIDs {
+			ids = append(ids, id.Hex())
+		}
+		filters = append(filters, map[string]interface{}{"terms": map[string]interface{}{"folder_id": ids}})
+	} else if f.FolderID != nil {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"folder_id": f.FolderID.Hex()}})
+	}
+
+	if f.TopicID != nil {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{"topic_id": f.TopicID.Hex()}})
+	}
+
+	filters = appendElasticRange(filters, "original_price_store", f.MinPriceStore, f.MaxPriceStore)
+	filters = appendElasticRange(filters, "original_price_service", f.MinPriceService, f.MaxPriceService)
+	filters = appendElasticRange(filters, "created_at", f.CreatedFrom, f.CreatedTo)
+	filters = appendElasticRange(filters, "updated_at", f.UpdatedFrom, f.UpdatedTo)
+
+	return filters
+}
+
+func appendElasticRange[T float64 | time.Time](filters []interface{}, field string, from, to *T) []interface{} {
+
+	bounds := map[string]interface{}{}
+
+	if from != nil {
+		bounds["gte"] = *from
+	}
+
+	if to != nil {
+		bounds["lte"] = *to
+	}
+
+	if len(bounds) == 0 {
+		return filters
+	}
+
+	return append(filters, map[string]interface{}{"range": map[string]interface{}{field: bounds}})
+}
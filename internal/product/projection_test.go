@@ -0,0 +1,596 @@
+package product
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"product-service/internal/folder"
+	"product-service/pkg/elastic"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// fakeProductRepository serves products from memory. Methods the tests do
+// not use panic through the nil embedded interface.
+type fakeProductRepository struct {
+	ProductRepository
+	products []*Product
+	filters  []*ProductFilter
+	updates  []bson.M
+}
+
+func (r *fakeProductRepository) GetProduct(ctx context.Context, id primitive.ObjectID) (*Product, error) {
+
+	for _, product := range r.products {
+		if product.ID == id {
+			copied := *product
+			copied.Variations = append([]Variation(nil), product.Variations...)
+			return &copied, nil
+		}
+	}
+
+	return nil, mongo.ErrNoDocuments
+}
+
+func (r *fakeProductRepository) UpdateVariations(ctx context.Context, id primitive.ObjectID, variations []Variation) error {
+	return nil
+}
+
+func (r *fakeProductRepository) UpdateProduct(ctx context.Context, id primitive.ObjectID, changes bson.M) error {
+	r.updates = append(r.updates, changes)
+	return nil
+}
+
+func (r *fakeProductRepository) GetProductsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Product, error) {
+
+	var products []*Product
+	for _, id := range ids {
+		for _, product := range r.products {
+			if product.ID == id {
+				products = append(products, product)
+			}
+		}
+	}
+
+	return products, nil
+}
+
+func (r *fakeProductRepository) IterateProducts(ctx context.Context, filter *ProductFilter, fn func(product *Product) error) error {
+
+	r.filters = append(r.filters, filter)
+
+	for _, product := range r.products {
+		if len(filter.FolderIDs) > 0 && !containsID(filter.FolderIDs, product.FolderID) {
+			continue
+		}
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeProductRepository) GetAllProducts(ctx context.Context, query *ProductQuery) ([]*Product, int64, error) {
+	return r.products, int64(len(r.products)), nil
+}
+
+func (r *fakeProductRepository) SearchProducts(ctx context.Context, query *SearchQuery) ([]*SearchHit, int64, error) {
+	return nil, 0, nil
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeEnricher resolves folder paths from folderNames, so a rename shows up
+// in the documents indexed afterwards.
+type fakeEnricher struct {
+	ProductEnricher
+	folderNames map[primitive.ObjectID]string
+}
+
+func (e *fakeEnricher) Enrich(ctx context.Context, products []*Product) []*ProductResponse {
+
+	responses := make([]*ProductResponse, 0, len(products))
+	for _, product := range products {
+		responses = append(responses, &ProductResponse{
+			ID:             product.ID,
+			EffectivePrice: EffectivePrice{PriceStore: product.OriginPriceStore, PriceService: product.OriginPriceService},
+			Folder: Folder{
+				ID:   product.FolderID.Hex(),
+				Name: e.folderNames[product.FolderID],
+				Breadcrumb: []Breadcrumb{
+					{ID: product.FolderID.Hex(), Name: e.folderNames[product.FolderID]},
+				},
+			},
+		})
+	}
+
+	return responses
+}
+
+type fakeFolderRepository struct {
+	folders     []*folder.Folder
+	descendants map[primitive.ObjectID][]primitive.ObjectID
+}
+
+func (r *fakeFolderRepository) GetFolder(ctx context.Context, id primitive.ObjectID) (*folder.Folder, error) {
+	return nil, nil
+}
+
+func (r *fakeFolderRepository) GetFoldersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*folder.Folder, error) {
+
+	var folders []*folder.Folder
+	for _, f := range r.folders {
+		if containsID(ids, f.ID) {
+			folders = append(folders, f)
+		}
+	}
+
+	return folders, nil
+}
+
+func (r *fakeFolderRepository) GetDescendantIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	return r.descendants[id], nil
+}
+
+// fakeElastic is an in-memory stand-in for the Elasticsearch endpoints the
+// projection calls.
+type fakeElastic struct {
+	mu            sync.Mutex
+	indexExists   bool
+	created       map[string]interface{}
+	docs          map[string]productDocument
+	bulkRequests  int
+	deleteQueries []map[string]interface{}
+	searches      []map[string]interface{}
+	searchHits    string
+}
+
+func newFakeElastic(t *testing.T) (*fakeElastic, *elastic.Client) {
+	t.Helper()
+
+	es := &fakeElastic{docs: make(map[string]productDocument)}
+
+	server := httptest.NewServer(http.HandlerFunc(es.serve(t)))
+	t.Cleanup(server.Close)
+
+	return es, elastic.NewClient(server.URL, "", "")
+}
+
+func (es *fakeElastic) serve(t *testing.T) func(w http.ResponseWriter, r *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		es.mu.Lock()
+		defer es.mu.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/products":
+			if !es.indexExists {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case r.Method == http.MethodPut && r.URL.Path == "/products":
+			if err := json.Unmarshal(body, &es.created); err != nil {
+				t.Error(err)
+			}
+			es.indexExists = true
+			_, _ = io.WriteString(w, `{"acknowledged":true}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/products/_bulk":
+			es.bulkRequests++
+			es.bulk(t, body)
+			_, _ = io.WriteString(w, `{"errors":false,"items":[]}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/products/_delete_by_query":
+			var query map[string]interface{}
+			if err := json.Unmarshal(body, &query); err != nil {
+				t.Error(err)
+			}
+			es.deleteQueries = append(es.deleteQueries, query)
+			_, _ = io.WriteString(w, `{"deleted":0}`)
+		case r.Method == http.MethodPost && r.URL.Path == "/products/_search":
+			var search map[string]interface{}
+			if err := json.Unmarshal(body, &search); err != nil {
+				t.Error(err)
+			}
+			es.searches = append(es.searches, search)
+			_, _ = io.WriteString(w, es.searchHits)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func (es *fakeElastic) bulk(t *testing.T, body []byte) {
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		var meta map[string]map[string]string
+		if err := json.Unmarshal(scanner.Bytes(), &meta); err != nil {
+			t.Errorf("bulk meta %q: %v", scanner.Text(), err)
+			return
+		}
+
+		if del, ok := meta["delete"]; ok {
+			delete(es.docs, del["_id"])
+			continue
+		}
+
+		if !scanner.Scan() {
+			t.Error("bulk index action without a document")
+			return
+		}
+
+		var doc productDocument
+		if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+			t.Errorf("bulk doc %q: %v", scanner.Text(), err)
+			return
+		}
+		es.docs[meta["index"]["_id"]] = doc
+	}
+}
+
+func (es *fakeElastic) docIDs() []string {
+
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	ids := make([]string, 0, len(es.docs))
+	for id := range es.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+func sortedHexes(ids ...primitive.ObjectID) []string {
+
+	hexes := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hexes = append(hexes, id.Hex())
+	}
+	sort.Strings(hexes)
+
+	return hexes
+}
+
+type projectionFixture struct {
+	es         *fakeElastic
+	repository *fakeProductRepository
+	enricher   *fakeEnricher
+	folders    *fakeFolderRepository
+	projection *ElasticProjection
+}
+
+func newProjectionFixture(t *testing.T, products ...*Product) *projectionFixture {
+	t.Helper()
+
+	es, client := newFakeElastic(t)
+
+	f := &projectionFixture{
+		es:         es,
+		repository: &fakeProductRepository{products: products},
+		enricher:   &fakeEnricher{folderNames: make(map[primitive.ObjectID]string)},
+		folders:    &fakeFolderRepository{descendants: make(map[primitive.ObjectID][]primitive.ObjectID)},
+	}
+	f.projection = NewElasticProjection(client, "products", f.repository, f.folders, f.enricher, "service-token", 0)
+
+	return f
+}
+
+func TestElasticProjectionEnsureIndex(t *testing.T) {
+
+	f := newProjectionFixture(t)
+
+	if err := f.projection.EnsureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if f.es.created == nil || f.es.created["mappings"] == nil {
+		t.Fatalf("index created with %v, want the product mappings", f.es.created)
+	}
+
+	f.es.created = nil
+
+	if err := f.projection.EnsureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if f.es.created != nil {
+		t.Error("EnsureIndex recreated an existing index")
+	}
+}
+
+func TestElasticProjectionSync(t *testing.T) {
+
+	folderID := primitive.NewObjectID()
+	lamp := &Product{ID: primitive.NewObjectID(), ProductName: "Lamp", FolderID: folderID, OriginPriceStore: 12.5}
+	deletedID := primitive.NewObjectID()
+
+	f := newProjectionFixture(t, lamp)
+	f.enricher.folderNames[folderID] = "Lighting"
+	f.es.docs[deletedID.Hex()] = productDocument{ProductName: "Gone"}
+
+	if err := f.projection.Sync(context.Background(), []primitive.ObjectID{lamp.ID, deletedID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := f.es.docIDs(), sortedHexes(lamp.ID); !equalStrings(got, want) {
+		t.Fatalf("indexed %v, want %v", got, want)
+	}
+
+	doc := f.es.docs[lamp.ID.Hex()]
+	if doc.ProductName != "Lamp" || doc.FolderID != folderID.Hex() || doc.FolderPath != "Lighting" {
+		t.Errorf("document = %+v", doc)
+	}
+	if doc.EffectivePriceStore != 12.5 {
+		t.Errorf("effective_price_store = %v, want 12.5", doc.EffectivePriceStore)
+	}
+	if doc.IndexedAt.IsZero() {
+		t.Error("indexed_at not set")
+	}
+}
+
+func TestElasticProjectionFolderChanged(t *testing.T) {
+
+	renamedID := primitive.NewObjectID()
+	targetID := primitive.NewObjectID()
+
+	tests := []struct {
+		name   string
+		change folder.FolderChange
+		want   *primitive.ObjectID
+	}{
+		{name: "created", change: folder.FolderChange{ID: renamedID, Type: folder.ChangeCreated}},
+		{name: "renamed", change: folder.FolderChange{ID: renamedID, Type: folder.ChangeRenamed}, want: &renamedID},
+		{name: "moved", change: folder.FolderChange{ID: renamedID, Type: folder.ChangeMoved}, want: &renamedID},
+		{name: "cascade delete", change: folder.FolderChange{ID: renamedID, Type: folder.ChangeDeleted}},
+		{name: "reparent delete", change: folder.FolderChange{ID: renamedID, Type: folder.ChangeDeleted, TargetID: &targetID}, want: &targetID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			f := newProjectionFixture(t)
+
+			if err := f.projection.FolderChanged(context.Background(), tt.change); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case id := <-f.projection.folderChanges:
+				if tt.want == nil {
+					t.Fatalf("queued folder %s, want nothing", id.Hex())
+				}
+				if id != *tt.want {
+					t.Errorf("queued folder %s, want %s", id.Hex(), tt.want.Hex())
+				}
+			default:
+				if tt.want != nil {
+					t.Fatalf("queued nothing, want folder %s", tt.want.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestElasticProjectionSyncFolder(t *testing.T) {
+
+	parentID := primitive.NewObjectID()
+	childID := primitive.NewObjectID()
+	otherID := primitive.NewObjectID()
+
+	inParent := &Product{ID: primitive.NewObjectID(), ProductName: "Lamp", FolderID: parentID}
+	inChild := &Product{ID: primitive.NewObjectID(), ProductName: "Bulb", FolderID: childID}
+	elsewhere := &Product{ID: primitive.NewObjectID(), ProductName: "Desk", FolderID: otherID}
+
+	f := newProjectionFixture(t, inParent, inChild, elsewhere)
+	f.folders.descendants[parentID] = []primitive.ObjectID{childID}
+	f.enricher.folderNames[parentID] = "Lighting"
+	f.enricher.folderNames[childID] = "Bulbs"
+
+	if err := f.projection.Sync(context.Background(), []primitive.ObjectID{inParent.ID, inChild.ID, elsewhere.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	f.enricher.folderNames[parentID] = "Lights"
+	f.enricher.folderNames[childID] = "Light bulbs"
+	f.enricher.folderNames[otherID] = "Renamed elsewhere"
+
+	if err := f.projection.syncFolder(context.Background(), parentID); err != nil {
+		t.Fatal(err)
+	}
+
+	filter := f.repository.filters[len(f.repository.filters)-1]
+	if got, want := sortedHexes(filter.FolderIDs...), sortedHexes(parentID, childID); !equalStrings(got, want) {
+		t.Errorf("iterated folders %v, want %v", got, want)
+	}
+
+	if path := f.es.docs[inParent.ID.Hex()].FolderPath; path != "Lights" {
+		t.Errorf("parent product folder path = %q, want Lights", path)
+	}
+	if path := f.es.docs[inChild.ID.Hex()].FolderPath; path != "Light bulbs" {
+		t.Errorf("child product folder path = %q, want Light bulbs", path)
+	}
+	if path := f.es.docs[elsewhere.ID.Hex()].FolderPath; path != "" {
+		t.Errorf("product outside the folder reindexed with path %q", path)
+	}
+}
+
+func TestElasticProjectionRun(t *testing.T) {
+
+	folderID := primitive.NewObjectID()
+	lamp := &Product{ID: primitive.NewObjectID(), ProductName: "Lamp", FolderID: folderID}
+	desk := &Product{ID: primitive.NewObjectID(), ProductName: "Desk", FolderID: folderID}
+
+	f := newProjectionFixture(t, lamp, desk)
+	f.enricher.folderNames[folderID] = "Home"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		f.projection.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if err := f.projection.ProductChanged(ctx, ProductChange{ID: lamp.ID, Type: ChangeCreated}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.projection.FolderChanged(ctx, folder.FolderChange{ID: folderID, Type: folder.ChangeRenamed}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * projectionFlushInterval)
+	want := sortedHexes(lamp.ID, desk.ID)
+	for !equalStrings(f.es.docIDs(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("indexed %v, want %v", f.es.docIDs(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestElasticProjectionReindex(t *testing.T) {
+
+	folderID := primitive.NewObjectID()
+	products := make([]*Product, 0, projectionBatchSize+1)
+	for i := 0; i <= projectionBatchSize; i++ {
+		products = append(products, &Product{ID: primitive.NewObjectID(), FolderID: folderID})
+	}
+
+	f := newProjectionFixture(t, products...)
+
+	started := time.Now()
+
+	count, err := f.projection.Reindex(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != len(products) {
+		t.Errorf("Reindex count = %d, want %d", count, len(products))
+	}
+	if len(f.es.docs) != len(products) {
+		t.Errorf("indexed %d documents, want %d", len(f.es.docs), len(products))
+	}
+	if f.es.bulkRequests != 2 {
+		t.Errorf("sent %d bulk requests, want 2", f.es.bulkRequests)
+	}
+
+	if len(f.es.deleteQueries) != 1 {
+		t.Fatalf("sent %d delete by query requests, want 1", len(f.es.deleteQueries))
+	}
+
+	query := f.es.deleteQueries[0]["query"].(map[string]interface{})
+	bound := query["range"].(map[string]interface{})["indexed_at"].(map[string]interface{})["lt"].(string)
+	cutoff, err := time.Parse(time.RFC3339Nano, bound)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cutoff.Before(started.Add(-time.Second)) || cutoff.After(time.Now()) {
+		t.Errorf("stale documents cut off at %v, want the reindex start", cutoff)
+	}
+}
+
+func TestElasticProjectionSearchProducts(t *testing.T) {
+
+	folderID := primitive.NewObjectID()
+	lamp := &Product{ID: primitive.NewObjectID(), ProductName: "Desk lamp", ProductDescription: "A lamp & a shade", FolderID: folderID}
+	desk := &Product{ID: primitive.NewObjectID(), ProductName: "Desk", ProductDescription: "Oak", FolderID: folderID}
+	deletedID := primitive.NewObjectID()
+
+	f := newProjectionFixture(t, lamp, desk)
+	f.es.searchHits = `{"hits":{"total":{"value":3},"hits":[` +
+		`{"_id":"` + lamp.ID.Hex() + `","_score":2.5,"highlight":{"product_name":["Desk <em>lamp</em>"]}},` +
+		`{"_id":"` + deletedID.Hex() + `","_score":2.0},` +
+		`{"_id":"` + desk.ID.Hex() + `","_score":1.5}]}}`
+
+	query := &SearchQuery{
+		Text:   "lamp",
+		Filter: ProductFilter{FolderID: &folderID},
+		Skip:   10,
+		Limit:  5,
+	}
+
+	hits, total, err := f.projection.SearchProducts(context.Background(), query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	if len(hits) != 2 {
+		t.Fatalf("got %d hits, want 2 with the deleted product skipped", len(hits))
+	}
+
+	if hits[0].ID != lamp.ID || hits[0].Score != 2.5 || hits[0].Highlights.ProductName != "Desk <em>lamp</em>" {
+		t.Errorf("hit 0 = %+v, highlights %+v", hits[0].Product, hits[0].Highlights)
+	}
+	if hits[1].ID != desk.ID || hits[1].Highlights.ProductName != "Desk" {
+		t.Errorf("hit 1 = %+v, highlights %+v", hits[1].Product, hits[1].Highlights)
+	}
+
+	search := f.es.searches[0]
+	if search["from"] != float64(10) || search["size"] != float64(5) {
+		t.Errorf("paging = from %v size %v, want from 10 size 5", search["from"], search["size"])
+	}
+
+	body, _ := json.Marshal(search["query"])
+	if !strings.Contains(string(body), `"lamp"`) || !strings.Contains(string(body), folderID.Hex()) {
+		t.Errorf("query = %s, want the text and the folder filter", body)
+	}
+}
+
+func TestElasticProjectionSearchRejectsSpecs(t *testing.T) {
+
+	f := newProjectionFixture(t)
+
+	query := &SearchQuery{Text: "lamp", Filter: ProductFilter{Specs: []*SpecCondition{{}}}}
+
+	if _, _, err := f.projection.SearchProducts(context.Background(), query); err != ErrUnsupportedSearchFilter {
+		t.Errorf("SearchProducts error = %v, want ErrUnsupportedSearchFilter", err)
+	}
+	if len(f.es.searches) != 0 {
+		t.Error("searched Elasticsearch with an unsupported filter")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,18 +2,76 @@ package product
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type ProductRepository interface {
+	EnsureIndexes(ctx context.Context) error
 	CreateProduct(ctx context.Context, product *Product) (string, error)
-	GetAllProducts(ctx context.Context) ([]*Product, error)
+	GetAllProducts(ctx context.Context, query *ProductQuery) ([]*Product, int64, error)
+	GetAllProductsWithFacets(ctx context.Context, query *ProductQuery, facets *FacetQuery) ([]*Product, int64, *FacetCounts, error)
 	GetProduct(ctx context.Context, id primitive.ObjectID) (*Product, error)
-	UpdateProduct(ctx context.Context, product *Product) error
+	GetProductsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Product, error)
+	ProductExists(ctx context.Context, id primitive.ObjectID) (bool, error)
+	UpdateProduct(ctx context.Context, id primitive.ObjectID, changes bson.M) error
 	DeleteProduct(ctx context.Context, id primitive.ObjectID) error
+	UpdateVariations(ctx context.Context, id primitive.ObjectID, variations []Variation) error
+	UpdateSpecifications(ctx context.Context, id primitive.ObjectID, specifications []Specification) error
+	UpdateImages(ctx context.Context, id primitive.ObjectID, images []ProductImage) error
+	UpdateQRCode(ctx context.Context, id primitive.ObjectID, qrCode string) error
+	UpdateSearch(ctx context.Context, id primitive.ObjectID, search *ProductSearch) error
+	SearchProducts(ctx context.Context, query *SearchQuery) ([]*SearchHit, int64, error)
+	IterateProducts(ctx context.Context, filter *ProductFilter, fn func(product *Product) error) error
+	CountProductsByFolder(ctx context.Context, folderIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+	ProductImageKeysByFolders(ctx context.Context, folderIDs []primitive.ObjectID) ([]string, error)
+	ImageKeyReferenced(ctx context.Context, key string) (bool, error)
+	DeleteProductsByFolders(ctx context.Context, folderIDs []primitive.ObjectID) (int64, error)
+	MoveProductsToFolder(ctx context.Context, folderIDs []primitive.ObjectID, targetID primitive.ObjectID) (int64, error)
+}
+
+type ProductFilter struct {
+	FolderID        *primitive.ObjectID
+	FolderIDs       []primitive.ObjectID
+	TopicID         *primitive.ObjectID
+	MinPriceStore   *float64
+	MaxPriceStore   *float64
+	MinPriceService *float64
+	MaxPriceService *float64
+	CreatedFrom     *time.Time
+	CreatedTo       *time.Time
+	UpdatedFrom     *time.Time
+	UpdatedTo       *time.Time
+	Specs           []*SpecCondition
+}
+
+type ProductQuery struct {
+	Filter    ProductFilter
+	SortField string
+	SortDesc  bool
+	Skip      int64
+	Limit     int64
+	After     *cursorPosition
+}
+
+// SearchQuery is a Mongo $text search over the folded search fields,
+// narrowed by the listing filters.
+type SearchQuery struct {
+	Text   string
+	Filter ProductFilter
+	Skip   int64
+	Limit  int64
+}
+
+type SearchHit struct {
+	Product `bson:",inline"`
+	Score   float64 `bson:"score"`
+	// Highlights is set by backends that highlight matches themselves.
+	Highlights *SearchHighlights `bson:"-"`
 }
 
 type productRepository struct {
@@ -26,6 +84,26 @@ func NewProductRepository(collection *mongo.Collection) ProductRepository {
 	}
 }
 
+// EnsureIndexes creates the text index behind product search. The index uses
+// the "none" language so Vietnamese words are neither stemmed nor dropped as
+// stop words, and weighs name matches above description matches.
+func (r *productRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "search.name", Value: "text"},
+			{Key: "search.description", Value: "text"},
+		},
+		Options: options.Index().
+			SetName("product_search").
+			SetDefaultLanguage("none").
+			SetWeights(bson.M{"search.name": 10, "search.description": 1}),
+	})
+
+	return err
+
+}
+
 func (r *productRepository) CreateProduct(ctx context.Context, product *Product) (string, error) {
 	
 	result, err := r.collection.InsertOne(ctx, product)
@@ -37,22 +115,104 @@ func (r *productRepository) CreateProduct(ctx context.Context, product *Product)
 
 }
 
-func (r *productRepository) GetAllProducts(ctx context.Context) ([]*Product, error) {
+func (r *productRepository) GetAllProducts(ctx context.Context, query *ProductQuery) ([]*Product, int64, error) {
 
 	var products []*Product
 
-	cursor, err := r.collection.Find(ctx, bson.M{})
+	filter := query.Filter.toBSON()
+
+	total, err := r.collection.CountDocuments(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	if query.After != nil {
+		filter = bson.M{"$and": bson.A{filter, query.After.filter(query.SortDesc)}}
+	}
+
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: query.SortField, Value: direction}, {Key: "_id", Value: direction}}).
+		SetSkip(query.Skip).
+		SetLimit(query.Limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	err = cursor.All(ctx, &products)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	return products, nil
-	
+	return products, total, nil
+
+}
+
+// GetAllProductsWithFacets returns the page, the total and the facet counts
+// from a single $facet aggregation over the filtered products.
+func (r *productRepository) GetAllProductsWithFacets(ctx context.Context, query *ProductQuery, facets *FacetQuery) ([]*Product, int64, *FacetCounts, error) {
+
+	direction := 1
+	if query.SortDesc {
+		direction = -1
+	}
+
+	page := bson.A{}
+
+	if query.After != nil {
+		page = append(page, bson.M{"$match": query.After.filter(query.SortDesc)})
+	}
+
+	page = append(page,
+		bson.M{"$sort": bson.D{{Key: query.SortField, Value: direction}, {Key: "_id", Value: direction}}},
+		bson.M{"$skip": query.Skip},
+		bson.M{"$limit": query.Limit},
+	)
+
+	stages := facetStages(facets)
+	stages["products"] = page
+	stages["total"] = bson.A{bson.M{"$count": "count"}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: query.Filter.toBSON()}},
+		{{Key: "$facet", Value: stages}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		FacetCounts `bson:",inline"`
+		Products    []*Product `bson:"products"`
+		Total       []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+	}
+
+	if !cursor.Next(ctx) {
+		return nil, 0, nil, cursor.Err()
+	}
+
+	if err := cursor.Decode(&result); err != nil {
+		return nil, 0, nil, err
+	}
+
+	var total int64
+	if len(result.Total) > 0 {
+		total = result.Total[0].Count
+	}
+
+	return result.Products, total, &result.FacetCounts, nil
+
 }
 
 func (r *productRepository) GetProduct(ctx context.Context, id primitive.ObjectID) (*Product, error) {
@@ -70,11 +230,46 @@ func (r *productRepository) GetProduct(ctx context.Context, id primitive.ObjectI
 
 }
 
-func (r *productRepository) UpdateProduct(ctx context.Context, product *Product) error {
+func (r *productRepository) GetProductsByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*Product, error) {
+
+	var products []*Product
+
+	filter := bson.M{"_id": bson.M{"$in": ids}}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &products)
+	if err != nil {
+		return nil, err
+	}
 
-	filter := bson.M{"_id": product.ID}
+	return products, nil
 
-	update := bson.M{"$set": product}
+}
+
+func (r *productRepository) ProductExists(ctx context.Context, id primitive.ObjectID) (bool, error) {
+
+	filter := bson.M{"_id": id}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+// UpdateProduct sets only the given fields. Variations, specifications and
+// images are written by their own endpoints and are never part of changes.
+func (r *productRepository) UpdateProduct(ctx context.Context, id primitive.ObjectID, changes bson.M) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": changes}
 	
 	_, err := r.collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -96,4 +291,319 @@ func (r *productRepository) DeleteProduct(ctx context.Context, id primitive.Obje
 	
 	return nil
 	
-}
\ No newline at end of file
+}
+
+func (r *productRepository) UpdateVariations(ctx context.Context, id primitive.ObjectID, variations []Variation) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": bson.M{
+		"variations": variations,
+		"updated_at": time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *productRepository) UpdateSpecifications(ctx context.Context, id primitive.ObjectID, specifications []Specification) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": bson.M{
+		"specifications": specifications,
+		"updated_at":     time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *productRepository) UpdateImages(ctx context.Context, id primitive.ObjectID, images []ProductImage) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": bson.M{
+		"images":     images,
+		"updated_at": time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// CountProductsByFolder groups products by folder in a single aggregation.
+// A nil folderIDs counts every folder.
+func (r *productRepository) CountProductsByFolder(ctx context.Context, folderIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+
+	pipeline := mongo.Pipeline{}
+
+	if folderIDs != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"folder_id": bson.M{"$in": folderIDs}}}})
+	}
+
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.M{
+		"_id":   "$folder_id",
+		"count": bson.M{"$sum": 1},
+	}}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		FolderID primitive.ObjectID `bson:"_id"`
+		Count    int64              `bson:"count"`
+	}
+
+	err = cursor.All(ctx, &rows)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[primitive.ObjectID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.FolderID] = row.Count
+	}
+
+	return counts, nil
+
+}
+
+func (r *productRepository) ProductImageKeysByFolders(ctx context.Context, folderIDs []primitive.ObjectID) ([]string, error) {
+
+	var products []*Product
+
+	filter := bson.M{"folder_id": bson.M{"$in": folderIDs}}
+
+	opts := options.Find().SetProjection(bson.M{"cover_image": 1, "images": 1, "variations": 1})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &products)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, product := range products {
+		for key := range productImageKeys(product) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+
+}
+
+// ImageKeyReferenced reports whether any product still uses key as its
+// cover, in its gallery or as a variation option image.
+func (r *productRepository) ImageKeyReferenced(ctx context.Context, key string) (bool, error) {
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"cover_image": key},
+		bson.M{"images.key": key},
+		bson.M{"variations.options.image": key},
+	}}
+
+	count, err := r.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+
+}
+
+func (r *productRepository) DeleteProductsByFolders(ctx context.Context, folderIDs []primitive.ObjectID) (int64, error) {
+
+	filter := bson.M{"folder_id": bson.M{"$in": folderIDs}}
+
+	result, err := r.collection.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+
+}
+
+func (r *productRepository) MoveProductsToFolder(ctx context.Context, folderIDs []primitive.ObjectID, targetID primitive.ObjectID) (int64, error) {
+
+	filter := bson.M{"folder_id": bson.M{"$in": folderIDs}}
+
+	update := bson.M{"$set": bson.M{
+		"folder_id":  targetID,
+		"updated_at": time.Now(),
+	}}
+
+	result, err := r.collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+
+}
+
+func (r *productRepository) UpdateQRCode(ctx context.Context, id primitive.ObjectID, qrCode string) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": bson.M{
+		"qrcode":     qrCode,
+		"updated_at": time.Now(),
+	}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// IterateProducts streams every product matching filter to fn in _id order
+// without loading the whole result set. Iteration stops at the first error
+// fn returns.
+func (r *productRepository) IterateProducts(ctx context.Context, filter *ProductFilter, fn func(product *Product) error) error {
+
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter.toBSON(), opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+
+		var product Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+
+	return cursor.Err()
+
+}
+
+func (r *productRepository) UpdateSearch(ctx context.Context, id primitive.ObjectID, search *ProductSearch) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": bson.M{"search": search}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *productRepository) SearchProducts(ctx context.Context, query *SearchQuery) ([]*SearchHit, int64, error) {
+
+	var hits []*SearchHit
+
+	filter := query.Filter.toBSON()
+	filter["$text"] = bson.M{"$search": query.Text}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	score := bson.M{"$meta": "textScore"}
+
+	opts := options.Find().
+		SetProjection(bson.M{"score": score}).
+		SetSort(bson.D{{Key: "score", Value: score}, {Key: "_id", Value: 1}}).
+		SetSkip(query.Skip).
+		SetLimit(query.Limit)
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = cursor.All(ctx, &hits)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+
+}
+
+func (f *ProductFilter) toBSON() bson.M {
+
+	filter := bson.M{}
+
+	if len(f.FolderIDs) > 0 {
+		filter["folder_id"] = bson.M{"$in": f.FolderIDs}
+	} else if f.FolderID != nil {
+		filter["folder_id"] = *f.FolderID
+	}
+
+	if f.TopicID != nil {
+		filter["topic_id"] = *f.TopicID
+	}
+
+	addRange(filter, "original_price_store", f.MinPriceStore, f.MaxPriceStore)
+	addRange(filter, "original_price_service", f.MinPriceService, f.MaxPriceService)
+	addRange(filter, "created_at", f.CreatedFrom, f.CreatedTo)
+	addRange(filter, "updated_at", f.UpdatedFrom, f.UpdatedTo)
+
+	if len(f.Specs) > 0 {
+		conditions := bson.A{}
+		for _, spec := range f.Specs {
+			conditions = append(conditions, spec.toBSON())
+		}
+		filter["$and"] = conditions
+	}
+
+	return filter
+}
+
+func addRange[T float64 | time.Time](filter bson.M, field string, from, to *T) {
+
+	bounds := bson.M{}
+
+	if from != nil {
+		bounds["$gte"] = *from
+	}
+
+	if to != nil {
+		bounds["$lte"] = *to
+	}
+
+	if len(bounds) > 0 {
+		filter[field] = bounds
+	}
+}
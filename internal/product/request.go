@@ -1,23 +1,120 @@
 package product
 
 type CreateProductRequest struct {
-	ProductName        string  `json:"product_name" bson:"product_name"`
-	OriginPriceStore   float64 `json:"original_price_store" bson:"original_price_store"`
-	OriginPriceService float64 `json:"original_price_service" bson:"original_price_service"`
-	ProductDescription string  `json:"product_description" bson:"product_description"`
-	CoverImage         string  `json:"cover_image" bson:"cover_image"`
-	TopicID            string  `json:"topic_id" bson:"topic_id"`
-	FolderID           string  `json:"folder_id" bson:"folder_id"`
-	QRCode             string  `json:"qrcode" bson:"qrcode"`
+	ProductName        string       `json:"product_name" bson:"product_name"`
+	OriginPriceStore   float64      `json:"original_price_store" bson:"original_price_store"`
+	OriginPriceService float64      `json:"original_price_service" bson:"original_price_service"`
+	ProductDescription string       `json:"product_description" bson:"product_description"`
+	CoverImage         string       `json:"cover_image" bson:"cover_image"`
+	TopicID            string       `json:"topic_id" bson:"topic_id"`
+	FolderID           string       `json:"folder_id" bson:"folder_id"`
+	QRCode             string       `json:"qrcode" bson:"qrcode"`
+	UsageConfig        *UsageConfig `json:"usage_config" bson:"usage_config"`
+	VideoUrl           string       `json:"video_url" bson:"video_url"`
 }
 
 type UpdateProductRequest struct {
-	ProductName        string  `json:"product_name" bson:"product_name"`
-	OriginPriceStore   float64 `json:"original_price_store" bson:"original_price_store"`
-	OriginPriceService float64 `json:"original_price_service" bson:"original_price_service"`
-	ProductDescription string  `json:"product_description" bson:"product_description"`
-	CoverImage         string  `json:"cover_image" bson:"cover_image"`
-	TopicID            string  `json:"topic_id" bson:"topic_id"`
-	FolderID           string  `json:"folder_id" bson:"folder_id"`
-	QRCode             string  `json:"qrcode" bson:"qrcode"`
+	ProductName        string       `json:"product_name" bson:"product_name"`
+	OriginPriceStore   float64      `json:"original_price_store" bson:"original_price_store"`
+	OriginPriceService float64      `json:"original_price_service" bson:"original_price_service"`
+	ProductDescription string       `json:"product_description" bson:"product_description"`
+	CoverImage         string       `json:"cover_image" bson:"cover_image"`
+	TopicID            string       `json:"topic_id" bson:"topic_id"`
+	FolderID           string       `json:"folder_id" bson:"folder_id"`
+	QRCode             string       `json:"qrcode" bson:"qrcode"`
+	UsageConfig        *UsageConfig `json:"usage_config" bson:"usage_config"`
+	VideoUrl           string       `json:"video_url" bson:"video_url"`
+}
+
+type GetProductsRequest struct {
+	Page     int    `form:"page"`
+	Size     int    `form:"size"`
+	Cursor   string `form:"cursor"`
+	Sort     string `form:"sort"`
+	FolderID string `form:"folder_id"`
+	// IncludeDescendants widens the folder_id filter to all nested folders.
+	IncludeDescendants bool     `form:"include_descendants"`
+	TopicID            string   `form:"topic_id"`
+	MinPriceStore      *float64 `form:"min_price_store"`
+	MaxPriceStore      *float64 `form:"max_price_store"`
+	MinPriceService    *float64 `form:"min_price_service"`
+	MaxPriceService    *float64 `form:"max_price_service"`
+	CreatedFrom        string   `form:"created_from"`
+	CreatedTo          string   `form:"created_to"`
+	UpdatedFrom        string   `form:"updated_from"`
+	UpdatedTo          string   `form:"updated_to"`
+	Specs              []string `form:"-"`
+	// Facets adds folder, topic, price and created date counts to the listing.
+	Facets       bool `form:"facets"`
+	FacetBuckets int  `form:"facet_buckets"`
+}
+
+type VariationRequest struct {
+	VariationName string                   `json:"variation_name"`
+	Options       []VariationOptionRequest `json:"options"`
+}
+
+type VariationOptionRequest struct {
+	ID     string   `json:"id"`
+	Option string   `json:"option"`
+	Price  *float64 `json:"price"`
+	Stock  int      `json:"stock"`
+	Image  string   `json:"image"`
+}
+
+type SpecificationRequest struct {
+	AttributeName string      `json:"attribute_name"`
+	Type          string      `json:"type"`
+	Value         interface{} `json:"value"`
+	Unit          string      `json:"unit"`
+	Options       []string    `json:"options"`
+}
+
+type UpdateSpecificationsRequest struct {
+	Specifications []SpecificationRequest `json:"specifications"`
+}
+
+type AddImageRequest struct {
+	Key       string `json:"key"`
+	AltText   string `json:"alt_text"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type UpdateImageRequest struct {
+	Key       string  `json:"key"`
+	AltText   *string `json:"alt_text"`
+	IsPrimary *bool   `json:"is_primary"`
+}
+
+type ReorderImagesRequest struct {
+	Keys []string `json:"keys"`
+}
+
+type QRCodeRequest struct {
+	Format string `form:"format"`
+	Size   int    `form:"size"`
+	ECC    string `form:"ecc"`
+	Margin *int   `form:"margin"`
+}
+
+type LabelRequest struct {
+	ProductIDs         []string `json:"product_ids"`
+	FolderID           string   `json:"folder_id"`
+	IncludeDescendants bool     `json:"include_descendants"`
+	Layout             string   `json:"layout"`
+	Copies             int      `json:"copies"`
+}
+
+// ExportProductsRequest takes the same filters as the product listing;
+// paging and sort parameters are ignored.
+type ExportProductsRequest struct {
+	GetProductsRequest
+	Format string `form:"format"`
+}
+
+// SearchProductsRequest combines a full-text query with the listing
+// filters. Results are ranked by relevance, so sort and cursor do not apply.
+type SearchProductsRequest struct {
+	GetProductsRequest
+	Query string `form:"q"`
 }
@@ -7,17 +7,63 @@ import (
 )
 
 type ProductResponse struct {
-	ID                 primitive.ObjectID `json:"id" bson:"_id"`
-	ProductName        string             `json:"product_name" bson:"product_name"`
-	OriginPriceStore   float64            `json:"original_price_store" bson:"original_price_store"`
-	OriginPriceService float64            `json:"original_price_service" bson:"original_price_service"`
-	ProductDescription string             `json:"product_description" bson:"product_description"`
-	CoverImage         string             `json:"cover_image" bson:"cover_image"`
-	Topic              *Topic              `json:"topic" bson:"topic"`
-	Folder             Folder             `json:"folder" bson:"folder"`
-	QRCode             string             `json:"qrcode" bson:"qrcode"`
-	CreatedAt          time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt          time.Time          `json:"updated_at" bson:"updated_at"`
+	ID                 primitive.ObjectID     `json:"id" bson:"_id"`
+	ProductName        string                 `json:"product_name" bson:"product_name"`
+	OriginPriceStore   float64                `json:"original_price_store" bson:"original_price_store"`
+	OriginPriceService float64                `json:"original_price_service" bson:"original_price_service"`
+	EffectivePrice     EffectivePrice         `json:"effective_price" bson:"effective_price"`
+	ProductDescription string                 `json:"product_description" bson:"product_description"`
+	CoverImage         string                 `json:"cover_image" bson:"cover_image"`
+	Topic              *Topic                 `json:"topic" bson:"topic"`
+	Folder             Folder                 `json:"folder" bson:"folder"`
+	QRCode             string                 `json:"qrcode" bson:"qrcode"`
+	Variations         []VariationResponse    `json:"variations" bson:"variations"`
+	Specifications     []Specification        `json:"specifications" bson:"specifications"`
+	UsageConfig        *UsageConfig           `json:"usage_config" bson:"usage_config"`
+	Images             []ProductImageResponse `json:"images" bson:"images"`
+	VideoUrl           string                 `json:"video_url" bson:"video_url"`
+	CreatedAt          time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt          time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+type VariationResponse struct {
+	ID            string                    `json:"id"`
+	VariationName string                    `json:"variation_name"`
+	Options       []VariationOptionResponse `json:"options"`
+}
+
+type VariationOptionResponse struct {
+	ID       string   `json:"id"`
+	Option   string   `json:"option"`
+	Price    *float64 `json:"price"`
+	Stock    int      `json:"stock"`
+	Image    string   `json:"image"`
+	ImageKey string   `json:"image_key"`
+}
+
+type ProductImageResponse struct {
+	Key       string `json:"key"`
+	Url       string `json:"url"`
+	AltText   string `json:"alt_text"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type EffectivePrice struct {
+	PriceStore   float64           `json:"price_store"`
+	PriceService float64           `json:"price_service"`
+	Promotion    *AppliedPromotion `json:"promotion,omitempty"`
+}
+
+type AppliedPromotion struct {
+	ID                   string    `json:"id"`
+	Name                 string    `json:"name"`
+	DiscountType         string    `json:"discount_type"`
+	DiscountOff          float64   `json:"discount_off,omitempty"`
+	DiscountPriceStore   *float64  `json:"discount_price_store,omitempty"`
+	DiscountPriceService *float64  `json:"discount_price_service,omitempty"`
+	PurchaseLimit        int       `json:"purchase_limit"`
+	PromotionStock       *int      `json:"promotion_stock"`
+	EndAt                time.Time `json:"end_at"`
 }
 
 type Topic struct {
@@ -26,6 +72,74 @@ type Topic struct {
 }
 
 type Folder struct {
-	ID   string `json:"id" bson:"_id"`
-	Name string `json:"name" bson:"name"`
+	ID         string       `json:"id" bson:"_id"`
+	Name       string       `json:"name" bson:"name"`
+	Breadcrumb []Breadcrumb `json:"breadcrumb" bson:"breadcrumb"`
+}
+
+type Breadcrumb struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type ProductListResponse struct {
+	Products   []*ProductResponse `json:"products"`
+	Pagination Pagination         `json:"pagination"`
+	Facets     *Facets            `json:"facets,omitempty"`
+}
+
+// Facets count the products matching the active filters, regardless of
+// the page.
+type Facets struct {
+	Folders      []FacetValue     `json:"folders"`
+	Topics       []FacetValue     `json:"topics"`
+	PriceStore   []PriceFacet     `json:"price_store"`
+	PriceService []PriceFacet     `json:"price_service"`
+	CreatedAt    []DateRangeFacet `json:"created_at"`
+}
+
+type FacetValue struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// PriceFacet covers prices from Min up to but excluding Max, except for the
+// last bucket, which includes Max.
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+type DateRangeFacet struct {
+	Key   string    `json:"key"`
+	From  time.Time `json:"from"`
+	Count int64     `json:"count"`
+}
+
+type Pagination struct {
+	Page       int    `json:"page,omitempty"`
+	Size       int    `json:"size"`
+	Total      int64  `json:"total"`
+	TotalPages int64  `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type SearchResponse struct {
+	Results    []*SearchResult `json:"results"`
+	Pagination Pagination      `json:"pagination"`
+}
+
+type SearchResult struct {
+	Product    *ProductResponse `json:"product"`
+	Score      float64          `json:"score"`
+	Highlights SearchHighlights `json:"highlights"`
+}
+
+// SearchHighlights are HTML-escaped with matched words wrapped in <em>.
+type SearchHighlights struct {
+	ProductName        string `json:"product_name"`
+	ProductDescription string `json:"product_description"`
 }
@@ -2,17 +2,40 @@ package product
 
 import (
 	"product-service/internal/middleware"
+	"product-service/pkg/auth"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, ProductHandler *ProductHandler) {
-	productGroup := r.Group("api/v1/products", middleware.Secured())
+func RegisterRoutes(r *gin.Engine, ProductHandler *ProductHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	productGroup := r.Group("api/v1/products", middleware.Secured(verifier))
 	{
-		productGroup.GET("", ProductHandler.GetAllProducts)
-		productGroup.GET("/:id", ProductHandler.GetProduct)
-		productGroup.POST("", ProductHandler.CreateProduct)
-		productGroup.PUT("/:id", ProductHandler.UpdateProduct)
-		productGroup.DELETE("/:id", ProductHandler.DeleteProduct)
+		productGroup.GET("", authorizer.Require(auth.ProductRead), ProductHandler.GetAllProducts)
+		productGroup.GET("/search", authorizer.Require(auth.ProductRead), ProductHandler.SearchProducts)
+		productGroup.GET("/export", authorizer.Require(auth.ProductRead), ProductHandler.ExportProducts)
+		productGroup.GET("/labels/layouts", authorizer.Require(auth.ProductRead), ProductHandler.GetLabelLayouts)
+		productGroup.POST("/labels", authorizer.Require(auth.ProductRead), ProductHandler.GenerateLabels)
+		productGroup.GET("/:id", authorizer.Require(auth.ProductRead), ProductHandler.GetProduct)
+		productGroup.POST("", authorizer.Require(auth.ProductWrite), ProductHandler.CreateProduct)
+		productGroup.PUT("/:id", authorizer.Require(auth.ProductWrite), ProductHandler.UpdateProduct)
+		productGroup.DELETE("/:id", authorizer.Require(auth.ProductDelete), ProductHandler.DeleteProduct)
+
+		productGroup.GET("/:id/variations", authorizer.Require(auth.ProductRead), ProductHandler.GetVariations)
+		productGroup.POST("/:id/variations", authorizer.Require(auth.ProductWrite), ProductHandler.CreateVariation)
+		productGroup.PUT("/:id/variations/:variation_id", authorizer.Require(auth.ProductWrite), ProductHandler.UpdateVariation)
+		productGroup.DELETE("/:id/variations/:variation_id", authorizer.Require(auth.ProductWrite), ProductHandler.DeleteVariation)
+
+		productGroup.GET("/:id/specifications", authorizer.Require(auth.ProductRead), ProductHandler.GetSpecifications)
+		productGroup.PUT("/:id/specifications", authorizer.Require(auth.ProductWrite), ProductHandler.UpdateSpecifications)
+		productGroup.POST("/:id/specifications", authorizer.Require(auth.ProductWrite), ProductHandler.AddSpecification)
+		productGroup.DELETE("/:id/specifications/:attribute_name", authorizer.Require(auth.ProductWrite), ProductHandler.DeleteSpecification)
+
+		productGroup.GET("/:id/qrcode", authorizer.Require(auth.ProductRead), ProductHandler.GetQRCode)
+
+		productGroup.GET("/:id/images", authorizer.Require(auth.ProductRead), ProductHandler.GetImages)
+		productGroup.POST("/:id/images", authorizer.Require(auth.ProductWrite), ProductHandler.AddImage)
+		productGroup.PATCH("/:id/images", authorizer.Require(auth.ProductWrite), ProductHandler.UpdateImage)
+		productGroup.PUT("/:id/images/order", authorizer.Require(auth.ProductWrite), ProductHandler.ReorderImages)
+		productGroup.DELETE("/:id/images", authorizer.Require(auth.ProductWrite), ProductHandler.RemoveImage)
 	}
-}
\ No newline at end of file
+}
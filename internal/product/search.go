@@ -0,0 +1,194 @@
+package product
+
+import (
+	"context"
+	"errors"
+	"html"
+	"product-service/pkg/text"
+	"strings"
+	"unicode"
+)
+
+const (
+	snippetLength  = 160
+	snippetContext = 60
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+)
+
+var ErrEmptySearch = errors.New("search query is required")
+
+// SearchBackend runs full-text product queries. The product repository
+// implements it with the Mongo text index.
+type SearchBackend interface {
+	SearchProducts(ctx context.Context, query *SearchQuery) ([]*SearchHit, int64, error)
+}
+
+// NewProductSearch folds name and description into their searchable form.
+func NewProductSearch(name, description string) *ProductSearch {
+	return &ProductSearch{
+		Name:        foldSearchText(name),
+		Description: foldSearchText(description),
+	}
+}
+
+func foldSearchText(s string) string {
+	return strings.ToLower(text.Fold(s))
+}
+
+// searchTerms returns the words of q that a result is expected to contain.
+// Negated words and the quotes around phrases follow the Mongo $search
+// syntax and are not highlighted.
+func searchTerms(q string) [][]rune {
+
+	var terms [][]rune
+
+	for _, field := range strings.Fields(foldSearchText(q)) {
+		if strings.HasPrefix(field, "-") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(field, isNotWordRune) {
+			terms = append(terms, []rune(word))
+		}
+	}
+
+	return terms
+}
+
+func isNotWordRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// matchedRunes reports, for every rune of s, whether it belongs to a whole
+// word equal to one of terms once both are folded. Folding works rune by
+// rune, so positions in the folded text map straight back to s.
+func matchedRunes(s string, terms [][]rune) []bool {
+
+	runes := []rune(s)
+	matched := make([]bool, len(runes))
+
+	var folded []rune
+	var origin []int
+
+	for i, r := range runes {
+		for _, f := range foldSearchText(string(r)) {
+			folded = append(folded, f)
+			origin = append(origin, i)
+		}
+	}
+
+	for _, term := range terms {
+		for start := 0; start+len(term) <= len(folded); start++ {
+			end := start + len(term)
+
+			if start > 0 && !isNotWordRune(folded[start-1]) {
+				continue
+			}
+			if end < len(folded) && !isNotWordRune(folded[end]) {
+				continue
+			}
+			if string(folded[start:end]) != string(term) {
+				continue
+			}
+
+			for i := start; i < end; i++ {
+				matched[origin[i]] = true
+			}
+		}
+	}
+
+	return matched
+}
+
+// highlight escapes runes[from:to] as HTML and wraps matched words in <em>.
+func highlight(runes []rune, matched []bool, from, to int) string {
+
+	var b strings.Builder
+	open := false
+
+	for i := from; i < to; i++ {
+		if matched[i] != open {
+			if open {
+				b.WriteString(highlightClose)
+			} else {
+				b.WriteString(highlightOpen)
+			}
+			open = matched[i]
+		}
+		b.WriteString(html.EscapeString(string(runes[i])))
+	}
+
+	if open {
+		b.WriteString(highlightClose)
+	}
+
+	return b.String()
+}
+
+func highlightText(s string, terms [][]rune) string {
+	runes := []rune(s)
+	return highlight(runes, matchedRunes(s, terms), 0, len(runes))
+}
+
+// snippet cuts a window of about snippetLength runes around the first match
+// in s, on word boundaries, and highlights the matches inside it. Text
+// without a match yields its opening words.
+func snippet(s string, terms [][]rune) string {
+
+	runes := []rune(s)
+	matched := matchedRunes(s, terms)
+
+	if len(runes) <= snippetLength {
+		return highlight(runes, matched, 0, len(runes))
+	}
+
+	first := 0
+	for i, m := range matched {
+		if m {
+			first = i
+			break
+		}
+	}
+
+	start := first - snippetContext
+	if start <= 0 {
+		start = 0
+	} else {
+		for start < first && !unicode.IsSpace(runes[start-1]) {
+			start++
+		}
+	}
+
+	end := start + snippetLength
+	if end >= len(runes) {
+		end = len(runes)
+	} else {
+		for end > first && !unicode.IsSpace(runes[end]) {
+			end--
+		}
+	}
+
+	var b strings.Builder
+
+	if start > 0 {
+		b.WriteString("…")
+	}
+	b.WriteString(strings.TrimSpace(highlight(runes, matched, start, end)))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+
+	return b.String()
+}
+
+func hitHighlights(hit *SearchHit, terms [][]rune) SearchHighlights {
+
+	if hit.Highlights != nil {
+		return *hit.Highlights
+	}
+
+	return SearchHighlights{
+		ProductName:        highlightText(hit.ProductName, terms),
+		ProductDescription: snippet(hit.ProductDescription, terms),
+	}
+}
@@ -4,63 +4,71 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"product-service/internal/media"
 	"product-service/internal/shared/ports"
-	"product-service/internal/topic"
-	"product-service/pkg/uploader"
+	"product-service/pkg/mongotx"
+	"product-service/pkg/qr"
+	"strings"
 	"time"
 
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type ProductService interface {
 	CreateProduct(ctx context.Context, req *CreateProductRequest) (string, error)
-	GetAllProducts(ctx context.Context) ([]*ProductResponse, error)
+	GetAllProducts(ctx context.Context, req *GetProductsRequest) (*ProductListResponse, error)
 	GetProduct(ctx context.Context, id string) (*ProductResponse, error)
 	UpdateProduct(ctx context.Context, req *UpdateProductRequest, id string) error
 	DeleteProduct(ctx context.Context, id string) error
+	GetVariations(ctx context.Context, id string) ([]VariationResponse, error)
+	CreateVariation(ctx context.Context, req *VariationRequest, id string) (string, error)
+	UpdateVariation(ctx context.Context, req *VariationRequest, id string, variationID string) error
+	DeleteVariation(ctx context.Context, id string, variationID string) error
+	GetSpecifications(ctx context.Context, id string) ([]Specification, error)
+	UpdateSpecifications(ctx context.Context, req *UpdateSpecificationsRequest, id string) error
+	AddSpecification(ctx context.Context, req *SpecificationRequest, id string) error
+	DeleteSpecification(ctx context.Context, id string, attributeName string) error
+	GetImages(ctx context.Context, id string) ([]ProductImageResponse, error)
+	AddImage(ctx context.Context, req *AddImageRequest, id string) error
+	UpdateImage(ctx context.Context, req *UpdateImageRequest, id string) error
+	ReorderImages(ctx context.Context, req *ReorderImagesRequest, id string) error
+	RemoveImage(ctx context.Context, id string, key string) error
+	GetQRCode(ctx context.Context, req *QRCodeRequest, id string) (*qr.Code, error)
+	GenerateLabels(ctx context.Context, req *LabelRequest) ([]byte, error)
+	ExportProducts(ctx context.Context, req *ExportProductsRequest, w io.Writer) error
+	SearchProducts(ctx context.Context, req *SearchProductsRequest) (*SearchResponse, error)
 }
 
 type productService struct {
 	productRepostitory ProductRepository
+	enricher           ProductEnricher
+	images             media.ImageLifecycle
 	folderRepository   ports.FolderRepository
-	topicService       topic.TopicService
-	imageService       uploader.ImageService
+	signer             *qr.Signer
+	search             SearchBackend
+	transactions       mongotx.Runner
+	listeners          []ChangeListener
 }
 
-func NewProductService(productRepostitory ProductRepository, folderRepository ports.FolderRepository, topicService topic.TopicService, imageService uploader.ImageService) ProductService {
+func NewProductService(productRepostitory ProductRepository, enricher ProductEnricher, images media.ImageLifecycle, folderRepository ports.FolderRepository, signer *qr.Signer, search SearchBackend, transactions mongotx.Runner, listeners ...ChangeListener) ProductService {
 	return &productService{
 		productRepostitory: productRepostitory,
+		enricher:           enricher,
+		images:             images,
 		folderRepository:   folderRepository,
-		topicService:       topicService,
-		imageService:       imageService,
+		signer:             signer,
+		search:             search,
+		transactions:       transactions,
+		listeners:          listeners,
 	}
 }
 
 func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRequest) (string, error) {
 
-	if req.ProductName == "" {
-		return "", errors.New("product name is required")
-	}
-
-	if req.OriginPriceStore == 0 {
-		return "", errors.New("origin price store is required")
-	}
-
-	if req.OriginPriceService == 0 {
-		return "", errors.New("origin price service is required")
-	}
-
-	if req.FolderID == "" {
-		return "", errors.New("category id is required")
-	}
-
-	if req.TopicID == "" {
-		return "", errors.New("topic id is required")
-	}
-
-	if req.CoverImage == "" {
-		return "", errors.New("cover image is required")
+	if err := ValidateCreateProductRequest(req); err != nil {
+		return "", err
 	}
 
 	folderObjectID, err := primitive.ObjectIDFromHex(req.FolderID)
@@ -75,7 +83,7 @@ func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRe
 
 	ID := primitive.NewObjectID()
 
-	QRCocde := fmt.Sprintf("SENBOX.ORG[PRODUCT]:%s", ID.Hex())
+	QRCocde := s.signer.ProductPayload(ID.Hex())
 
 	product := &Product{
 		ID:                 ID,
@@ -87,11 +95,19 @@ func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRe
 		TopicID:            topicObjectID,
 		FolderID:           folderObjectID,
 		QRCode:             QRCocde,
+		UsageConfig:        req.UsageConfig,
+		VideoUrl:           req.VideoUrl,
+		Search:             NewProductSearch(req.ProductName, req.ProductDescription),
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 	}
 
-	id, err := s.productRepostitory.CreateProduct(ctx, product)
+	var id string
+
+	err = s.write(ctx, ChangeCreated, product.ID, product, func(ctx context.Context) error {
+		id, err = s.productRepostitory.CreateProduct(ctx, product)
+		return err
+	})
 
 	if err != nil {
 		return "", err
@@ -100,132 +116,298 @@ func (s *productService) CreateProduct(ctx context.Context, req *CreateProductRe
 	return id, nil
 }
 
-func (s *productService) GetAllProducts(ctx context.Context) ([]*ProductResponse, error) {
+// ValidateCreateProductRequest applies the rules a new product must satisfy.
+// It is shared with bulk import so both paths accept the same data.
+func ValidateCreateProductRequest(req *CreateProductRequest) error {
+
+	if req.ProductName == "" {
+		return errors.New("product name is required")
+	}
+
+	if req.OriginPriceStore == 0 {
+		return errors.New("origin price store is required")
+	}
+
+	if req.OriginPriceService == 0 {
+		return errors.New("origin price service is required")
+	}
+
+	if req.FolderID == "" {
+		return errors.New("category id is required")
+	}
+
+	if req.TopicID == "" {
+		return errors.New("topic id is required")
+	}
+
+	if req.CoverImage == "" {
+		return errors.New("cover image is required")
+	}
+
+	if !primitive.IsValidObjectID(req.FolderID) {
+		return fmt.Errorf("invalid folder id: %s", req.FolderID)
+	}
+
+	if !primitive.IsValidObjectID(req.TopicID) {
+		return fmt.Errorf("invalid topic id: %s", req.TopicID)
+	}
+
+	if err := validateUsageConfig(req.UsageConfig); err != nil {
+		return err
+	}
+
+	if err := validateVideoUrl(req.VideoUrl); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *productService) GetAllProducts(ctx context.Context, req *GetProductsRequest) (*ProductListResponse, error) {
+
+	query, err := s.productQuery(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Product
+	var total int64
+	var counts *FacetCounts
 
-	res, err := s.productRepostitory.GetAllProducts(ctx)
+	now := time.Now()
+
+	if req.Facets {
+		res, total, counts, err = s.productRepostitory.GetAllProductsWithFacets(ctx, query, &FacetQuery{
+			PriceBuckets: req.FacetBuckets,
+			Now:          now,
+		})
+	} else {
+		res, total, err = s.productRepostitory.GetAllProducts(ctx, query)
+	}
 	if err != nil {
 		if err.Error() == "mongo: no documents in result" {
-			return []*ProductResponse{}, nil
+			return &ProductListResponse{Products: []*ProductResponse{}}, nil
 		}
 		return nil, err
 	}
 
-	var products []*ProductResponse
+	hasMore := int64(len(res)) > query.Limit-1
+	if hasMore {
+		res = res[:len(res)-1]
+	}
+
+	pageSize := query.Limit - 1
+
+	pagination := Pagination{
+		Size:       int(pageSize),
+		Total:      total,
+		TotalPages: (total + pageSize - 1) / pageSize,
+		HasMore:    hasMore,
+	}
+
+	if query.After == nil {
+		pagination.Page = int(query.Skip/pageSize) + 1
+	}
+
+	if hasMore && len(res) > 0 {
+		pagination.NextCursor = encodeCursor(res[len(res)-1], query.SortField)
+	}
+
+	products := s.enricher.Enrich(ctx, res)
 
-	for _, product := range res {
+	response := &ProductListResponse{
+		Products:   products,
+		Pagination: pagination,
+	}
 
-		folder, err := s.folderRepository.GetFolder(ctx, product.FolderID)
+	if counts != nil {
+		response.Facets, err = s.buildFacets(ctx, counts, now)
 		if err != nil {
-			log.Println("Error getting folder:", err)
+			return nil, err
 		}
+	}
+
+	return response, nil
+}
 
-		topic, err := s.topicService.GetTopicByID(ctx, product.TopicID.Hex())
+// buildFacets names the folder and topic counts of the facet aggregation.
+func (s *productService) buildFacets(ctx context.Context, counts *FacetCounts, now time.Time) (*Facets, error) {
+
+	folderIDs := make([]primitive.ObjectID, 0, len(counts.Folders))
+	for _, c := range counts.Folders {
+		folderIDs = append(folderIDs, c.ID)
+	}
+
+	folderNames := make(map[primitive.ObjectID]string, len(folderIDs))
+
+	if len(folderIDs) > 0 {
+		folders, err := s.folderRepository.GetFoldersByIDs(ctx, folderIDs)
 		if err != nil {
-			log.Println("Error getting topic:", err)
+			return nil, err
 		}
-
-		topicResp := &Topic{}
-		if topic != nil {
-			topicResp.ID = topic.ID
-			topicResp.Name = topic.Name
+		for _, f := range folders {
+			folderNames[f.ID] = f.Name
 		}
+	}
 
-		folderResp := Folder{}
-		if folder != nil {
-			folderResp = Folder{
-				ID:   folder.ID.Hex(),
-				Name: folder.Name,
-			}
-		}
+	topicIDs := make([]string, 0, len(counts.Topics))
+	for _, c := range counts.Topics {
+		topicIDs = append(topicIDs, c.ID.Hex())
+	}
 
-		var image string
-		if product.CoverImage != "" {
-			img, err := s.imageService.GetImageKey(ctx, product.CoverImage)
-			if err != nil {
-				log.Println("Error getting image key:", err)
-			} else if img != nil {
-				image = img.Url
-			}
+	topicNames := make(map[primitive.ObjectID]string, len(topicIDs))
+	for id, t := range s.enricher.ResolveTopics(ctx, topicIDs) {
+		if objectID, err := primitive.ObjectIDFromHex(id); err == nil {
+			topicNames[objectID] = t.Name
 		}
+	}
 
-		products = append(products, &ProductResponse{
-			ID:                 product.ID,
-			ProductName:        product.ProductName,
-			OriginPriceStore:   product.OriginPriceStore,
-			OriginPriceService: product.OriginPriceService,
-			ProductDescription: product.ProductDescription,
-			CoverImage:         image,
-			Topic:              topicResp,
-			Folder:             folderResp,
-			QRCode:             product.QRCode,
-			CreatedAt:          product.CreatedAt,
-			UpdatedAt:          product.UpdatedAt,
-		})
+	return &Facets{
+		Folders:      toFacetValues(counts.Folders, folderNames),
+		Topics:       toFacetValues(counts.Topics, topicNames),
+		PriceStore:   toPriceFacets(counts.PriceStore),
+		PriceService: toPriceFacets(counts.PriceService),
+		CreatedAt:    toCreatedFacets(counts.Created, now),
+	}, nil
+
+}
+
+func validateUsageConfig(cfg *UsageConfig) error {
 
+	if cfg == nil {
+		return nil
 	}
 
-	return products, nil
+	if cfg.NumberOfUses < 0 || cfg.MinimumUsageTime < 0 || cfg.MaximumUsageTime < 0 {
+		return errors.New("usage config values must not be negative")
+	}
+
+	if cfg.MaximumUsageTime > 0 && cfg.MinimumUsageTime > cfg.MaximumUsageTime {
+		return errors.New("minimum usage time must not exceed maximum usage time")
+	}
+
+	return nil
 }
 
-func (s *productService) GetProduct(ctx context.Context, id string) (*ProductResponse, error) {
+// productQuery builds the listing query and widens the folder filter to
+// nested folders when the request asks for it.
+func (s *productService) productQuery(ctx context.Context, req *GetProductsRequest) (*ProductQuery, error) {
 
-	idObjectID, err := primitive.ObjectIDFromHex(id)
+	query, err := buildProductQuery(req)
 	if err != nil {
 		return nil, err
 	}
 
-	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if req.IncludeDescendants && query.Filter.FolderID != nil {
+		descendants, err := s.folderRepository.GetDescendantIDs(ctx, *query.Filter.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		query.Filter.FolderIDs = append([]primitive.ObjectID{*query.Filter.FolderID}, descendants...)
+	}
+
+	return query, nil
+
+}
+
+func buildProductQuery(req *GetProductsRequest) (*ProductQuery, error) {
+
+	size := req.Size
+	if size <= 0 {
+		size = defaultPageSize
+	}
+
+	if size > maxPageSize {
+		size = maxPageSize
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+
+	sortField, sortDesc, err := parseSort(req.Sort)
 	if err != nil {
 		return nil, err
 	}
 
-	folder, err := s.folderRepository.GetFolder(ctx, product.FolderID)
-	if err != nil {
-		log.Println("Error getting folder:", err)
+	query := &ProductQuery{
+		SortField: sortField,
+		SortDesc:  sortDesc,
+		// One extra document is fetched to tell whether another page exists.
+		Limit: int64(size) + 1,
 	}
 
-	topic, err := s.topicService.GetTopicByID(ctx, product.TopicID.Hex())
-	if err != nil {
-		log.Println("Error getting topic:", err)
+	if req.Cursor != "" {
+		query.After, err = decodeCursor(req.Cursor, sortField)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		query.Skip = int64((page - 1) * size)
 	}
 
-	topicResp := &Topic{}
-	if topic != nil {
-		topicResp.ID = topic.ID
-		topicResp.Name = topic.Name
+	if req.FolderID != "" {
+		folderObjectID, err := primitive.ObjectIDFromHex(req.FolderID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid folder id: %s", ErrInvalidFilter, req.FolderID)
+		}
+		query.Filter.FolderID = &folderObjectID
 	}
 
-	folderResp := Folder{}
-	if folder != nil {
-		folderResp = Folder{
-			ID:   folder.ID.Hex(),
-			Name: folder.Name,
+	if req.TopicID != "" {
+		topicObjectID, err := primitive.ObjectIDFromHex(req.TopicID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid topic id: %s", ErrInvalidFilter, req.TopicID)
 		}
+		query.Filter.TopicID = &topicObjectID
+	}
+
+	query.Filter.MinPriceStore = req.MinPriceStore
+	query.Filter.MaxPriceStore = req.MaxPriceStore
+	query.Filter.MinPriceService = req.MinPriceService
+	query.Filter.MaxPriceService = req.MaxPriceService
+
+	if query.Filter.CreatedFrom, err = parseDateBound(req.CreatedFrom, false); err != nil {
+		return nil, err
+	}
+
+	if query.Filter.CreatedTo, err = parseDateBound(req.CreatedTo, true); err != nil {
+		return nil, err
+	}
+
+	if query.Filter.UpdatedFrom, err = parseDateBound(req.UpdatedFrom, false); err != nil {
+		return nil, err
 	}
 
-	var image string
-	if product.CoverImage != "" {
-		img, err := s.imageService.GetImageKey(ctx, product.CoverImage)
+	if query.Filter.UpdatedTo, err = parseDateBound(req.UpdatedTo, true); err != nil {
+		return nil, err
+	}
+
+	for _, expression := range req.Specs {
+		condition, err := parseSpecCondition(expression)
 		if err != nil {
-			log.Println("Error getting image key:", err)
-		} else if img != nil {
-			image = img.Url
+			return nil, err
 		}
+		query.Filter.Specs = append(query.Filter.Specs, condition)
 	}
 
-	return &ProductResponse{
-		ID:                 product.ID,
-		ProductName:        product.ProductName,
-		OriginPriceStore:   product.OriginPriceStore,
-		OriginPriceService: product.OriginPriceService,
-		ProductDescription: product.ProductDescription,
-		CoverImage:         image,
-		Topic:              topicResp,
-		Folder:             folderResp,
-		QRCode:             product.QRCode,
-		CreatedAt:          product.CreatedAt,
-		UpdatedAt:          product.UpdatedAt,
-	}, nil
+	return query, nil
+}
+
+func (s *productService) GetProduct(ctx context.Context, id string) (*ProductResponse, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.enricher.EnrichOne(ctx, product), nil
 
 }
 
@@ -241,24 +423,35 @@ func (s *productService) UpdateProduct(ctx context.Context, req *UpdateProductRe
 		return err
 	}
 
+	referenced := productImageKeys(product)
+
+	// Only the edited fields are written: variations, specifications and
+	// images have their own endpoints and may change while this request runs.
+	changes := bson.M{}
+
 	if req.ProductName != "" {
 		product.ProductName = req.ProductName
+		changes["product_name"] = product.ProductName
 	}
 
 	if req.OriginPriceStore != 0 {
 		product.OriginPriceStore = req.OriginPriceStore
+		changes["original_price_store"] = product.OriginPriceStore
 	}
 
 	if req.OriginPriceService != 0 {
 		product.OriginPriceService = req.OriginPriceService
+		changes["original_price_service"] = product.OriginPriceService
 	}
 
 	if req.ProductDescription != "" {
 		product.ProductDescription = req.ProductDescription
+		changes["product_description"] = product.ProductDescription
 	}
 
 	if req.CoverImage != "" {
 		product.CoverImage = req.CoverImage
+		changes["cover_image"] = product.CoverImage
 	}
 
 	if req.TopicID != "" {
@@ -267,6 +460,7 @@ func (s *productService) UpdateProduct(ctx context.Context, req *UpdateProductRe
 			return err
 		}
 		product.TopicID = topicObjectID
+		changes["topic_id"] = product.TopicID
 	}
 
 	if req.FolderID != "" {
@@ -275,27 +469,42 @@ func (s *productService) UpdateProduct(ctx context.Context, req *UpdateProductRe
 			return err
 		}
 		product.FolderID = folderObjectID
+		changes["folder_id"] = product.FolderID
 	}
 
-	productData := &Product{
-		ID:                 product.ID,
-		ProductName:        product.ProductName,
-		OriginPriceStore:   product.OriginPriceStore,
-		OriginPriceService: product.OriginPriceService,
-		ProductDescription: product.ProductDescription,
-		CoverImage:         product.CoverImage,
-		TopicID:            product.TopicID,
-		FolderID:           product.FolderID,
-		QRCode:             product.QRCode,
-		CreatedAt:          product.CreatedAt,
-		UpdatedAt:          time.Now(),
+	if req.UsageConfig != nil {
+		if err := validateUsageConfig(req.UsageConfig); err != nil {
+			return err
+		}
+		product.UsageConfig = req.UsageConfig
+		changes["usage_config"] = product.UsageConfig
+	}
+
+	if req.VideoUrl != "" {
+		if err := validateVideoUrl(req.VideoUrl); err != nil {
+			return err
+		}
+		product.VideoUrl = req.VideoUrl
+		changes["video_url"] = product.VideoUrl
+	}
+
+	if req.ProductName != "" || req.ProductDescription != "" {
+		changes["search"] = NewProductSearch(product.ProductName, product.ProductDescription)
 	}
 
-	err = s.productRepostitory.UpdateProduct(ctx, productData)
+	changes["updated_at"] = time.Now()
+
+	// Listeners load the stored product, which carries sub-resource edits
+	// made since it was read here.
+	err = s.write(ctx, ChangeUpdated, product.ID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateProduct(ctx, product.ID, changes)
+	})
 	if err != nil {
 		return err
 	}
 
+	s.images.Release(ctx, releasedImageKeys(referenced, product)...)
+
 	return nil
 
 }
@@ -307,6 +516,667 @@ func (s *productService) DeleteProduct(ctx context.Context, id string) error {
 		return err
 	}
 
-	return s.productRepostitory.DeleteProduct(ctx, idObjectID)
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeDeleted, idObjectID, product, func(ctx context.Context) error {
+		return s.productRepostitory.DeleteProduct(ctx, idObjectID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.images.Release(ctx, releasedImageKeys(productImageKeys(product), nil)...)
+
+	return nil
+
+}
+
+func (s *productService) GetVariations(ctx context.Context, id string) ([]VariationResponse, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	images := s.enricher.ResolveImages(ctx, variationImageKeys(product.Variations))
+
+	return toVariationResponses(product.Variations, images), nil
+
+}
+
+func (s *productService) CreateVariation(ctx context.Context, req *VariationRequest, id string) (string, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return "", err
+	}
+
+	variation, err := buildVariation(primitive.NewObjectID(), req)
+	if err != nil {
+		return "", err
+	}
+
+	variations := append(product.Variations, *variation)
+
+	if err := validateVariationNames(variations); err != nil {
+		return "", err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateVariations(ctx, idObjectID, variations)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return variation.ID.Hex(), nil
+
+}
+
+func (s *productService) UpdateVariation(ctx context.Context, req *VariationRequest, id string, variationID string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	variationObjectID, err := primitive.ObjectIDFromHex(variationID)
+	if err != nil {
+		return ErrVariationNotFound
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	variation, err := buildVariation(variationObjectID, req)
+	if err != nil {
+		return err
+	}
+
+	referenced := productImageKeys(product)
+
+	found := false
+	for i := range product.Variations {
+		if product.Variations[i].ID == variationObjectID {
+			product.Variations[i] = *variation
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return ErrVariationNotFound
+	}
+
+	if err := validateVariationNames(product.Variations); err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateVariations(ctx, idObjectID, product.Variations)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.images.Release(ctx, releasedImageKeys(referenced, product)...)
+
+	return nil
+
+}
+
+func (s *productService) DeleteVariation(ctx context.Context, id string, variationID string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	variationObjectID, err := primitive.ObjectIDFromHex(variationID)
+	if err != nil {
+		return ErrVariationNotFound
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	variations := make([]Variation, 0, len(product.Variations))
+	for _, v := range product.Variations {
+		if v.ID != variationObjectID {
+			variations = append(variations, v)
+		}
+	}
+
+	if len(variations) == len(product.Variations) {
+		return ErrVariationNotFound
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateVariations(ctx, idObjectID, variations)
+	})
+	if err != nil {
+		return err
+	}
+
+	referenced := productImageKeys(product)
+	product.Variations = variations
+
+	s.images.Release(ctx, releasedImageKeys(referenced, product)...)
+
+	return nil
+
+}
+
+func (s *productService) GetSpecifications(ctx context.Context, id string) ([]Specification, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Specifications == nil {
+		return []Specification{}, nil
+	}
+
+	return product.Specifications, nil
+
+}
+
+func (s *productService) UpdateSpecifications(ctx context.Context, req *UpdateSpecificationsRequest, id string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	specifications, err := buildSpecifications(req.Specifications)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.productRepostitory.GetProduct(ctx, idObjectID); err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateSpecifications(ctx, idObjectID, specifications)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *productService) AddSpecification(ctx context.Context, req *SpecificationRequest, id string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	reqs := make([]SpecificationRequest, 0, len(product.Specifications)+1)
+	for _, spec := range product.Specifications {
+		reqs = append(reqs, SpecificationRequest{
+			AttributeName: spec.AttributeName,
+			Type:          spec.Type,
+			Value:         spec.Value,
+			Unit:          spec.Unit,
+			Options:       spec.Options,
+		})
+	}
+	reqs = append(reqs, *req)
+
+	specifications, err := buildSpecifications(reqs)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateSpecifications(ctx, idObjectID, specifications)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *productService) DeleteSpecification(ctx context.Context, id string, attributeName string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	specifications := make([]Specification, 0, len(product.Specifications))
+	for _, spec := range product.Specifications {
+		if !strings.EqualFold(spec.AttributeName, attributeName) {
+			specifications = append(specifications, spec)
+		}
+	}
+
+	if len(specifications) == len(product.Specifications) {
+		return ErrSpecificationNotFound
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateSpecifications(ctx, idObjectID, specifications)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *productService) GetImages(ctx context.Context, id string) ([]ProductImageResponse, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := s.enricher.ResolveImages(ctx, galleryImageKeys(product.Images))
+
+	return toImageResponses(product.Images, urls), nil
+
+}
+
+func (s *productService) AddImage(ctx context.Context, req *AddImageRequest, id string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	images, err := addImage(product.Images, req)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateImages(ctx, idObjectID, images)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *productService) UpdateImage(ctx context.Context, req *UpdateImageRequest, id string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	images, err := updateImage(product.Images, req)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateImages(ctx, idObjectID, images)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (s *productService) ReorderImages(ctx context.Context, req *ReorderImagesRequest, id string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	images, err := reorderImages(product.Images, req.Keys)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateImages(ctx, idObjectID, images)
+	})
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// RemoveImage drops the key from the gallery first and only then releases
+// the stored file, so a failed delete never leaves the product pointing at a
+// missing image. A key still used as cover or option image is kept.
+func (s *productService) RemoveImage(ctx context.Context, id string, key string) error {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return err
+	}
+
+	referenced := productImageKeys(product)
+
+	images, err := removeImage(product.Images, key)
+	if err != nil {
+		return err
+	}
+
+	err = s.write(ctx, ChangeUpdated, idObjectID, nil, func(ctx context.Context) error {
+		return s.productRepostitory.UpdateImages(ctx, idObjectID, images)
+	})
+	if err != nil {
+		return err
+	}
+
+	product.Images = images
+
+	s.images.Release(ctx, releasedImageKeys(referenced, product)...)
+
+	return nil
+
+}
+
+func (s *productService) GetQRCode(ctx context.Context, req *QRCodeRequest, id string) (*qr.Code, error) {
+
+	idObjectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepostitory.GetProduct(ctx, idObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := product.QRCode
+	if content == "" {
+		content = s.signer.ProductPayload(product.ID.Hex())
+	}
+
+	opts := qr.DefaultOptions()
+	opts.Format = req.Format
+	opts.Size = req.Size
+	opts.ECC = req.ECC
+	if req.Margin != nil {
+		opts.Margin = *req.Margin
+	}
+
+	return qr.New(content, opts)
+
+}
+
+func (s *productService) GenerateLabels(ctx context.Context, req *LabelRequest) ([]byte, error) {
+
+	if (len(req.ProductIDs) == 0) == (req.FolderID == "") {
+		return nil, fmt.Errorf("%w: exactly one of product ids or folder id is required", ErrInvalidLabelRequest)
+	}
+
+	layout, err := findLabelLayout(req.Layout)
+	if err != nil {
+		return nil, err
+	}
+
+	copies := req.Copies
+	if copies == 0 {
+		copies = 1
+	}
+
+	if copies < 0 || copies > 100 {
+		return nil, fmt.Errorf("%w: copies must be between 1 and 100", ErrInvalidLabelRequest)
+	}
+
+	var products []*Product
+
+	if len(req.ProductIDs) > 0 {
+		products, err = s.labelProductsByIDs(ctx, req.ProductIDs)
+	} else {
+		products, err = s.labelProductsByFolder(ctx, req.FolderID, req.IncludeDescendants)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(products)*copies > maxLabels {
+		return nil, fmt.Errorf("%w: at most %d labels can be printed at once", ErrInvalidLabelRequest, maxLabels)
+	}
+
+	return renderLabels(layout, products, copies)
+
+}
+
+// labelProductsByIDs keeps the order the ids were given in and fails on the
+// first id that does not exist.
+func (s *productService) labelProductsByIDs(ctx context.Context, ids []string) ([]*Product, error) {
+
+	objectIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objectID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid product id %q", ErrInvalidLabelRequest, id)
+		}
+		objectIDs = append(objectIDs, objectID)
+	}
+
+	found, err := s.productRepostitory.GetProductsByIDs(ctx, objectIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*Product, len(found))
+	for _, product := range found {
+		byID[product.ID] = product
+	}
+
+	products := make([]*Product, 0, len(objectIDs))
+	for _, id := range objectIDs {
+		product, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrLabelProductNotFound, id.Hex())
+		}
+		products = append(products, product)
+	}
+
+	return products, nil
+}
+
+func (s *productService) labelProductsByFolder(ctx context.Context, folderID string, includeDescendants bool) ([]*Product, error) {
+
+	folderObjectID, err := primitive.ObjectIDFromHex(folderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid folder id %q", ErrInvalidLabelRequest, folderID)
+	}
+
+	query := &ProductQuery{
+		Filter:    ProductFilter{FolderID: &folderObjectID},
+		SortField: "product_name",
+		Limit:     maxLabels + 1,
+	}
+
+	if includeDescendants {
+		descendants, err := s.folderRepository.GetDescendantIDs(ctx, folderObjectID)
+		if err != nil {
+			return nil, err
+		}
+		query.Filter.FolderIDs = append([]primitive.ObjectID{folderObjectID}, descendants...)
+	}
+
+	products, _, err := s.productRepostitory.GetAllProducts(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return products, nil
+}
+
+// ExportProducts streams every product matching the listing filters to w.
+// Products are read through a cursor and enriched in batches, so memory use
+// does not grow with the size of the catalog.
+func (s *productService) ExportProducts(ctx context.Context, req *ExportProductsRequest, w io.Writer) error {
+
+	query, err := s.productQuery(ctx, &req.GetProductsRequest)
+	if err != nil {
+		return err
+	}
+
+	writer, err := newExportWriter(req.Format, w)
+	if err != nil {
+		return err
+	}
+
+	batch := make([]*Product, 0, exportBatchSize)
+
+	flush := func() error {
+		responses := s.enricher.Enrich(ctx, batch)
+		for i, product := range batch {
+			if err := writer.Write(toExportRow(product, responses[i])); err != nil {
+				return err
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	err = s.productRepostitory.IterateProducts(ctx, &query.Filter, func(product *Product) error {
+		batch = append(batch, product)
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(batch) > 0 {
+		err = flush()
+	}
+	if err != nil {
+		writer.Discard()
+		return err
+	}
+
+	return writer.Close()
+
+}
+
+func (s *productService) SearchProducts(ctx context.Context, req *SearchProductsRequest) (*SearchResponse, error) {
+
+	q := strings.TrimSpace(req.Query)
+	if q == "" {
+		return nil, ErrEmptySearch
+	}
+
+	listing := req.GetProductsRequest
+	listing.Sort = ""
+	listing.Cursor = ""
+
+	query, err := s.productQuery(ctx, &listing)
+	if err != nil {
+		return nil, err
+	}
+
+	pageSize := query.Limit - 1
+
+	hits, total, err := s.search.SearchProducts(ctx, &SearchQuery{
+		Text:   foldSearchText(q),
+		Filter: query.Filter,
+		Skip:   query.Skip,
+		Limit:  pageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*Product, 0, len(hits))
+	for _, hit := range hits {
+		products = append(products, &hit.Product)
+	}
+
+	responses := s.enricher.Enrich(ctx, products)
+	terms := searchTerms(q)
+
+	results := make([]*SearchResult, 0, len(hits))
+	for i, hit := range hits {
+		results = append(results, &SearchResult{
+			Product:    responses[i],
+			Score:      hit.Score,
+			Highlights: hitHighlights(hit, terms),
+		})
+	}
+
+	return &SearchResponse{
+		Results: results,
+		Pagination: Pagination{
+			Page:       int(query.Skip/pageSize) + 1,
+			Size:       int(pageSize),
+			Total:      total,
+			TotalPages: (total + pageSize - 1) / pageSize,
+			HasMore:    query.Skip+int64(len(hits)) < total,
+		},
+	}, nil
 
 }
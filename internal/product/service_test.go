@@ -0,0 +1,78 @@
+package product
+
+import (
+	"context"
+	"product-service/pkg/mongotx"
+	"sort"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakeImageLifecycle struct {
+	released []string
+}
+
+func (l *fakeImageLifecycle) Release(ctx context.Context, keys ...string) {
+	l.released = append(l.released, keys...)
+}
+
+type recordingListener struct {
+	changes []ProductChange
+}
+
+func (l *recordingListener) ProductChanged(ctx context.Context, change ProductChange) error {
+	l.changes = append(l.changes, change)
+	return nil
+}
+
+func TestUpdateProductSetsOnlyEditedFields(t *testing.T) {
+
+	price := 5.0
+	product := &Product{
+		ID:          primitive.NewObjectID(),
+		ProductName: "Lamp",
+		CoverImage:  "old-cover.png",
+		Variations:  []Variation{{ID: primitive.NewObjectID(), VariationName: "Colour", Options: []VariationOption{{Option: "Red", Price: &price}}}},
+		Images:      []ProductImage{{Key: "side.png"}},
+	}
+
+	repository := &fakeProductRepository{products: []*Product{product}}
+	images := &fakeImageLifecycle{}
+	listener := &recordingListener{}
+
+	service := NewProductService(repository, &fakeEnricher{}, images, &fakeFolderRepository{}, nil, repository, mongotx.NewDirectRunner(), listener)
+
+	req := &UpdateProductRequest{ProductName: "Desk lamp", CoverImage: "new-cover.png"}
+	if err := service.UpdateProduct(context.Background(), req, product.ID.Hex()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repository.updates) != 1 {
+		t.Fatalf("got %d updates, want 1", len(repository.updates))
+	}
+
+	changes := repository.updates[0]
+
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	want := []string{"cover_image", "product_name", "search", "updated_at"}
+	if !equalStrings(fields, want) {
+		t.Errorf("set fields %v, want %v", fields, want)
+	}
+	if changes["product_name"] != "Desk lamp" || changes["cover_image"] != "new-cover.png" {
+		t.Errorf("changes = %v", changes)
+	}
+
+	if !equalStrings(images.released, []string{"old-cover.png"}) {
+		t.Errorf("released %v, want the replaced cover only", images.released)
+	}
+
+	if len(listener.changes) != 1 || listener.changes[0].Type != ChangeUpdated || listener.changes[0].Product != nil {
+		t.Errorf("listener got %+v, want one update that loads the stored product", listener.changes)
+	}
+}
@@ -0,0 +1,226 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"product-service/pkg/unit"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	SpecTypeString = "string"
+	SpecTypeNumber = "number"
+	SpecTypeBool   = "bool"
+	SpecTypeEnum   = "enum"
+
+	specQueryPrefix = "spec."
+)
+
+var (
+	ErrSpecificationNotFound = errors.New("specification not found")
+	ErrInvalidSpecFilter     = errors.New("invalid specification filter")
+)
+
+// SpecCondition is one "spec.<name><op><value>" listing filter. Numeric
+// values are normalized to the base unit of their dimension before matching.
+type SpecCondition struct {
+	Name     string
+	Operator string
+	Value    interface{}
+	BaseUnit string
+	Numeric  bool
+}
+
+var specOperators = []string{"<=", ">=", "!=", "<", ">", "="}
+
+var mongoOperators = map[string]string{
+	"<=": "$lte",
+	">=": "$gte",
+	"<":  "$lt",
+	">":  "$gt",
+	"=":  "$eq",
+	"!=": "$ne",
+}
+
+func buildSpecifications(reqs []SpecificationRequest) ([]Specification, error) {
+
+	specs := make([]Specification, 0, len(reqs))
+	seen := make(map[string]bool, len(reqs))
+
+	for _, req := range reqs {
+
+		spec, err := buildSpecification(&req)
+		if err != nil {
+			return nil, err
+		}
+
+		key := strings.ToLower(spec.AttributeName)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate specification %q", spec.AttributeName)
+		}
+		seen[key] = true
+
+		specs = append(specs, *spec)
+	}
+
+	return specs, nil
+}
+
+func buildSpecification(req *SpecificationRequest) (*Specification, error) {
+
+	name := strings.TrimSpace(req.AttributeName)
+	if name == "" {
+		return nil, errors.New("attribute name is required")
+	}
+
+	spec := &Specification{
+		AttributeName: name,
+		Type:          req.Type,
+		Unit:          strings.TrimSpace(req.Unit),
+	}
+
+	if spec.Type == "" {
+		spec.Type = SpecTypeString
+	}
+
+	if spec.Unit != "" && spec.Type != SpecTypeNumber {
+		return nil, fmt.Errorf("unit is only allowed on number specifications (%s)", name)
+	}
+
+	switch spec.Type {
+	case SpecTypeString:
+		value, ok := req.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a string", name)
+		}
+		spec.Value = value
+	case SpecTypeNumber:
+		value, ok := req.Value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a number", name)
+		}
+		normalized, base := unit.Normalize(value, spec.Unit)
+		spec.Value = value
+		spec.NormalizedValue = &normalized
+		spec.BaseUnit = base
+	case SpecTypeBool:
+		value, ok := req.Value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a boolean", name)
+		}
+		spec.Value = value
+	case SpecTypeEnum:
+		value, ok := req.Value.(string)
+		if !ok {
+			return nil, fmt.Errorf("value of %s must be a string", name)
+		}
+		if len(req.Options) == 0 {
+			return nil, fmt.Errorf("options are required for enum specification %s", name)
+		}
+		allowed := false
+		for _, option := range req.Options {
+			if option == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, fmt.Errorf("value %q of %s is not one of its options", value, name)
+		}
+		spec.Value = value
+		spec.Options = req.Options
+	default:
+		return nil, fmt.Errorf("unsupported specification type: %s", spec.Type)
+	}
+
+	return spec, nil
+}
+
+// specExpressions collects the "spec." query parameters. A parameter such as
+// "spec.weight<=2kg" reaches the server as key "spec.weight<" and value "2kg",
+// so the key and value are joined back into the original expression.
+func specExpressions(query url.Values) []string {
+
+	var expressions []string
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, specQueryPrefix) {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, specQueryPrefix)
+
+		for _, value := range values {
+			if value == "" {
+				expressions = append(expressions, name)
+				continue
+			}
+			expressions = append(expressions, name+"="+value)
+		}
+	}
+
+	return expressions
+}
+
+func parseSpecCondition(expression string) (*SpecCondition, error) {
+
+	for _, op := range specOperators {
+
+		index := strings.Index(expression, op)
+		if index <= 0 {
+			continue
+		}
+
+		name := strings.TrimSpace(expression[:index])
+		raw := strings.TrimSpace(expression[index+len(op):])
+
+		if raw == "" {
+			return nil, fmt.Errorf("%w: missing value: %s", ErrInvalidSpecFilter, expression)
+		}
+
+		condition := &SpecCondition{Name: name, Operator: op}
+
+		if value, u, err := unit.Parse(raw); err == nil {
+			normalized, base := unit.Normalize(value, u)
+			condition.Value = normalized
+			condition.BaseUnit = base
+			condition.Numeric = true
+			return condition, nil
+		}
+
+		if op != "=" && op != "!=" {
+			return nil, fmt.Errorf("%w: operator %s needs a numeric value with a known unit: %s", ErrInvalidSpecFilter, op, expression)
+		}
+
+		if b, err := strconv.ParseBool(raw); err == nil {
+			condition.Value = b
+		} else {
+			condition.Value = raw
+		}
+
+		return condition, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrInvalidSpecFilter, expression)
+}
+
+func (c *SpecCondition) toBSON() bson.M {
+
+	match := bson.M{
+		"attribute_name": bson.M{"$regex": "^" + regexp.QuoteMeta(c.Name) + "$", "$options": "i"},
+	}
+
+	if c.Numeric {
+		match["base_unit"] = c.BaseUnit
+		match["normalized_value"] = bson.M{mongoOperators[c.Operator]: c.Value}
+	} else {
+		match["value"] = bson.M{mongoOperators[c.Operator]: c.Value}
+	}
+
+	return bson.M{"specifications": bson.M{"$elemMatch": match}}
+}
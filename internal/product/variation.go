@@ -0,0 +1,134 @@
+package product
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrVariationNotFound = errors.New("variation not found")
+	ErrInvalidVariation  = errors.New("invalid variation")
+)
+
+func buildVariation(id primitive.ObjectID, req *VariationRequest) (*Variation, error) {
+
+	name := strings.TrimSpace(req.VariationName)
+	if name == "" {
+		return nil, fmt.Errorf("%w: variation name is required", ErrInvalidVariation)
+	}
+
+	if len(req.Options) == 0 {
+		return nil, fmt.Errorf("%w: at least one option is required", ErrInvalidVariation)
+	}
+
+	variation := &Variation{
+		ID:            id,
+		VariationName: name,
+		Options:       make([]VariationOption, 0, len(req.Options)),
+	}
+
+	seen := make(map[string]bool, len(req.Options))
+
+	for _, o := range req.Options {
+
+		option := strings.TrimSpace(o.Option)
+		if option == "" {
+			return nil, fmt.Errorf("%w: option value is required in variation %q", ErrInvalidVariation, name)
+		}
+
+		if seen[strings.ToLower(option)] {
+			return nil, fmt.Errorf("%w: duplicate option %q in variation %q", ErrInvalidVariation, option, name)
+		}
+		seen[strings.ToLower(option)] = true
+
+		if o.Price != nil && *o.Price < 0 {
+			return nil, fmt.Errorf("%w: price of option %q must not be negative", ErrInvalidVariation, option)
+		}
+
+		if o.Stock < 0 {
+			return nil, fmt.Errorf("%w: stock of option %q must not be negative", ErrInvalidVariation, option)
+		}
+
+		optionID := primitive.NewObjectID()
+		if o.ID != "" {
+			parsed, err := primitive.ObjectIDFromHex(o.ID)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid option id: %s", ErrInvalidVariation, o.ID)
+			}
+			optionID = parsed
+		}
+
+		variation.Options = append(variation.Options, VariationOption{
+			ID:     optionID,
+			Option: option,
+			Price:  o.Price,
+			Stock:  o.Stock,
+			Image:  o.Image,
+		})
+	}
+
+	return variation, nil
+}
+
+// validateVariationNames rejects two variation groups with the same name, so
+// every variation name and option value pair identifies a single option.
+func validateVariationNames(variations []Variation) error {
+
+	seen := make(map[string]bool, len(variations))
+
+	for _, v := range variations {
+		key := strings.ToLower(v.VariationName)
+		if seen[key] {
+			return fmt.Errorf("%w: duplicate variation %q", ErrInvalidVariation, v.VariationName)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+func variationImageKeys(variations []Variation) []string {
+
+	var keys []string
+
+	for _, v := range variations {
+		for _, o := range v.Options {
+			if o.Image != "" {
+				keys = append(keys, o.Image)
+			}
+		}
+	}
+
+	return keys
+}
+
+func toVariationResponses(variations []Variation, images map[string]string) []VariationResponse {
+
+	responses := make([]VariationResponse, 0, len(variations))
+
+	for _, v := range variations {
+
+		options := make([]VariationOptionResponse, 0, len(v.Options))
+		for _, o := range v.Options {
+			options = append(options, VariationOptionResponse{
+				ID:       o.ID.Hex(),
+				Option:   o.Option,
+				Price:    o.Price,
+				Stock:    o.Stock,
+				Image:    images[o.Image],
+				ImageKey: o.Image,
+			})
+		}
+
+		responses = append(responses, VariationResponse{
+			ID:            v.ID.Hex(),
+			VariationName: v.VariationName,
+			Options:       options,
+		})
+	}
+
+	return responses
+}
@@ -0,0 +1,164 @@
+package promotion
+
+import (
+	"errors"
+	"net/http"
+	"product-service/helper"
+	"product-service/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type PromotionHandler struct {
+	promotionService PromotionService
+}
+
+func NewPromotionHandler(promotionService PromotionService) *PromotionHandler {
+	return &PromotionHandler{
+		promotionService: promotionService,
+	}
+}
+
+func (h *PromotionHandler) CreatePromotion(ctx *gin.Context) {
+
+	var req CreatePromotionRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.promotionService.CreatePromotion(ctx, &req)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotion created successfully", res)
+
+}
+
+func (h *PromotionHandler) GetPromotions(ctx *gin.Context) {
+
+	var req GetPromotionsRequest
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.promotionService.GetPromotions(ctx, &req)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotions retrieved successfully", res)
+
+}
+
+func (h *PromotionHandler) GetPromotion(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	res, err := h.promotionService.GetPromotion(ctx, id)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			helper.SendError(ctx, http.StatusNotFound, errors.New("promotion not found"), nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotion retrieved successfully", res)
+
+}
+
+func (h *PromotionHandler) UpdatePromotion(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	var req UpdatePromotionRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	err := h.promotionService.UpdatePromotion(ctx, &req, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotion updated successfully", nil)
+
+}
+
+func (h *PromotionHandler) DeletePromotion(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	err := h.promotionService.DeletePromotion(ctx, id)
+	if err != nil {
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotion deleted successfully", nil)
+
+}
+
+func (h *PromotionHandler) RedeemPromotion(ctx *gin.Context) {
+
+	id := ctx.Param("id")
+
+	if id == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("id is required"), nil)
+		return
+	}
+
+	userID := ctx.GetString(constants.UserID)
+
+	if userID == "" {
+		helper.SendError(ctx, http.StatusUnauthorized, errors.New("user id not found in token"), nil)
+		return
+	}
+
+	var req RedeemPromotionRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	res, err := h.promotionService.RedeemPromotion(ctx, &req, id, userID)
+	if err != nil {
+		if errors.Is(err, ErrPromotionUnavailable) || errors.Is(err, ErrPurchaseLimitExceeded) {
+			helper.SendError(ctx, http.StatusConflict, err, nil)
+			return
+		}
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Promotion redeemed successfully", res)
+
+}
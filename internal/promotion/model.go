@@ -0,0 +1,42 @@
+package promotion
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	TypePercentage = "percentage"
+	TypeFixed      = "fixed"
+)
+
+type Promotion struct {
+	ID                   primitive.ObjectID  `json:"id" bson:"_id"`
+	Name                 string              `json:"name" bson:"name"`
+	ProductID            *primitive.ObjectID `json:"product_id" bson:"product_id"`
+	FolderID             *primitive.ObjectID `json:"folder_id" bson:"folder_id"`
+	DiscountType         string              `json:"discount_type" bson:"discount_type"`
+	DiscountOff          float64             `json:"discount_off" bson:"discount_off"`
+	DiscountPriceStore   *float64            `json:"discount_price_store" bson:"discount_price_store"`
+	DiscountPriceService *float64            `json:"discount_price_service" bson:"discount_price_service"`
+	PurchaseLimit        int                 `json:"purchase_limit" bson:"purchase_limit"`
+	PromotionStock       *int                `json:"promotion_stock" bson:"promotion_stock"`
+	Sold                 int                 `json:"sold" bson:"sold"`
+	DiscountEnabled      bool                `json:"discount_enabled" bson:"discount_enabled"`
+	Priority             int                 `json:"priority" bson:"priority"`
+	StartAt              time.Time           `json:"start_at" bson:"start_at"`
+	EndAt                time.Time           `json:"end_at" bson:"end_at"`
+	CreatedAt            time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt            time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Redemption counts the units of a promotion one user has redeemed, so the
+// purchase limit holds across redemptions.
+type Redemption struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	PromotionID primitive.ObjectID `json:"promotion_id" bson:"promotion_id"`
+	UserID      string             `json:"user_id" bson:"user_id"`
+	Quantity    int                `json:"quantity" bson:"quantity"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
@@ -0,0 +1,135 @@
+package promotion
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EffectivePrice struct {
+	PriceStore   float64
+	PriceService float64
+	Promotion    *Promotion
+}
+
+func (p *Promotion) IsActive(at time.Time) bool {
+
+	if !p.DiscountEnabled {
+		return false
+	}
+
+	if at.Before(p.StartAt) || !at.Before(p.EndAt) {
+		return false
+	}
+
+	if p.PromotionStock != nil && *p.PromotionStock <= 0 {
+		return false
+	}
+
+	return true
+}
+
+// Apply returns the store and service prices after the promotion. Prices
+// never drop below zero and a fixed price never raises the original price.
+func (p *Promotion) Apply(priceStore, priceService float64) (float64, float64) {
+
+	switch p.DiscountType {
+	case TypePercentage:
+		factor := 1 - p.DiscountOff/100
+		return roundPrice(priceStore * factor), roundPrice(priceService * factor)
+	case TypeFixed:
+		if p.DiscountPriceStore != nil {
+			priceStore = math.Min(priceStore, *p.DiscountPriceStore)
+		}
+		if p.DiscountPriceService != nil {
+			priceService = math.Min(priceService, *p.DiscountPriceService)
+		}
+	}
+
+	return math.Max(priceStore, 0), math.Max(priceService, 0)
+}
+
+// Resolve picks the promotion that applies to a product and computes its
+// effective prices. Among the active candidates targeting the product or its
+// folder, the winner is chosen by, in order:
+//
+//  1. product promotions before folder promotions,
+//  2. higher Priority,
+//  3. lower resulting store price, then lower resulting service price,
+//  4. the most recently started promotion.
+//
+// Without an active candidate the original prices are returned.
+func Resolve(promotions []*Promotion, productID primitive.ObjectID, folderIDs []primitive.ObjectID, priceStore, priceService float64, at time.Time) EffectivePrice {
+
+	type candidate struct {
+		promotion    *Promotion
+		productLevel bool
+		store        float64
+		service      float64
+	}
+
+	inFolder := make(map[primitive.ObjectID]bool, len(folderIDs))
+	for _, id := range folderIDs {
+		inFolder[id] = true
+	}
+
+	var candidates []candidate
+
+	for _, p := range promotions {
+
+		if !p.IsActive(at) {
+			continue
+		}
+
+		productLevel := p.ProductID != nil && *p.ProductID == productID
+		folderLevel := p.FolderID != nil && inFolder[*p.FolderID]
+
+		if !productLevel && !folderLevel {
+			continue
+		}
+
+		store, service := p.Apply(priceStore, priceService)
+
+		candidates = append(candidates, candidate{
+			promotion:    p,
+			productLevel: productLevel,
+			store:        store,
+			service:      service,
+		})
+	}
+
+	if len(candidates) == 0 {
+		return EffectivePrice{PriceStore: priceStore, PriceService: priceService}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.productLevel != b.productLevel {
+			return a.productLevel
+		}
+		if a.promotion.Priority != b.promotion.Priority {
+			return a.promotion.Priority > b.promotion.Priority
+		}
+		if a.store != b.store {
+			return a.store < b.store
+		}
+		if a.service != b.service {
+			return a.service < b.service
+		}
+		return a.promotion.StartAt.After(b.promotion.StartAt)
+	})
+
+	winner := candidates[0]
+
+	return EffectivePrice{
+		PriceStore:   winner.store,
+		PriceService: winner.service,
+		Promotion:    winner.promotion,
+	}
+}
+
+func roundPrice(price float64) float64 {
+	return math.Max(math.Round(price*100)/100, 0)
+}
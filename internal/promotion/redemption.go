@@ -0,0 +1,108 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrPurchaseLimitExceeded = errors.New("purchase limit exceeded")
+
+type RedemptionRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	Reserve(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int, limit int, at time.Time) error
+	Release(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int) error
+}
+
+type redemptionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRedemptionRepository(collection *mongo.Collection) RedemptionRepository {
+	return &redemptionRepository{
+		collection: collection,
+	}
+}
+
+// EnsureIndexes keeps a single counter per promotion and user, which is what
+// makes Reserve reject a redemption over the limit instead of starting a
+// second counter.
+func (r *redemptionRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "user_id", Value: 1}},
+		Options: options.Index().
+			SetName("one_counter_per_user").
+			SetUnique(true),
+	})
+
+	return err
+}
+
+// Reserve adds quantity to the user's counter only while the total stays
+// within limit. The caller checks quantity <= limit, so a first redemption
+// can always create the counter.
+func (r *redemptionRepository) Reserve(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int, limit int, at time.Time) error {
+
+	filter := bson.M{
+		"promotion_id": promotionID,
+		"user_id":      userID,
+		"quantity":     bson.M{"$lte": limit - quantity},
+	}
+
+	update := bson.M{
+		"$inc": bson.M{"quantity": quantity},
+		"$set": bson.M{"updated_at": at},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	// Either the user has no counter yet or it is too high to take quantity.
+	// The unique index tells the two apart.
+	_, err = r.collection.InsertOne(ctx, &Redemption{
+		PromotionID: promotionID,
+		UserID:      userID,
+		Quantity:    quantity,
+		UpdatedAt:   at,
+	})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrPurchaseLimitExceeded
+		}
+		return err
+	}
+
+	return nil
+
+}
+
+// Release gives back a reservation whose redemption did not go through.
+func (r *redemptionRepository) Release(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int) error {
+
+	filter := bson.M{
+		"promotion_id": promotionID,
+		"user_id":      userID,
+	}
+
+	update := bson.M{"$inc": bson.M{"quantity": -quantity}}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
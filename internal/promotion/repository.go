@@ -0,0 +1,178 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrPromotionUnavailable = errors.New("promotion is not active or out of stock")
+
+type PromotionRepository interface {
+	CreatePromotion(ctx context.Context, promotion *Promotion) (string, error)
+	GetPromotions(ctx context.Context, filter bson.M) ([]*Promotion, error)
+	GetPromotion(ctx context.Context, id primitive.ObjectID) (*Promotion, error)
+	GetActivePromotions(ctx context.Context, productIDs []primitive.ObjectID, folderIDs []primitive.ObjectID, at time.Time) ([]*Promotion, error)
+	UpdatePromotion(ctx context.Context, id primitive.ObjectID, changes bson.M) error
+	DeletePromotion(ctx context.Context, id primitive.ObjectID) error
+	Redeem(ctx context.Context, promotion *Promotion, quantity int, at time.Time) (*Promotion, error)
+}
+
+type promotionRepository struct {
+	collection *mongo.Collection
+}
+
+func NewPromotionRepository(collection *mongo.Collection) PromotionRepository {
+	return &promotionRepository{
+		collection: collection,
+	}
+}
+
+func (r *promotionRepository) CreatePromotion(ctx context.Context, promotion *Promotion) (string, error) {
+
+	result, err := r.collection.InsertOne(ctx, promotion)
+	if err != nil {
+		return "", err
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+
+}
+
+func (r *promotionRepository) GetPromotions(ctx context.Context, filter bson.M) ([]*Promotion, error) {
+
+	var promotions []*Promotion
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_at", Value: -1}})
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &promotions)
+	if err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+
+}
+
+func (r *promotionRepository) GetPromotion(ctx context.Context, id primitive.ObjectID) (*Promotion, error) {
+
+	var promotion Promotion
+
+	filter := bson.M{"_id": id}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+
+}
+
+func (r *promotionRepository) GetActivePromotions(ctx context.Context, productIDs []primitive.ObjectID, folderIDs []primitive.ObjectID, at time.Time) ([]*Promotion, error) {
+
+	if productIDs == nil {
+		productIDs = []primitive.ObjectID{}
+	}
+
+	if folderIDs == nil {
+		folderIDs = []primitive.ObjectID{}
+	}
+
+	filter := activeFilter(at)
+	filter["$or"] = bson.A{
+		bson.M{"product_id": bson.M{"$in": productIDs}},
+		bson.M{"folder_id": bson.M{"$in": folderIDs}},
+	}
+
+	return r.GetPromotions(ctx, filter)
+
+}
+
+// UpdatePromotion sets only the given fields, leaving sold and the stock
+// counters to Redeem.
+func (r *promotionRepository) UpdatePromotion(ctx context.Context, id primitive.ObjectID, changes bson.M) error {
+
+	filter := bson.M{"_id": id}
+
+	update := bson.M{"$set": changes}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+func (r *promotionRepository) DeletePromotion(ctx context.Context, id primitive.ObjectID) error {
+
+	filter := bson.M{"_id": id}
+
+	_, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return nil
+
+}
+
+// Redeem consumes quantity units of the promotion stock in a single
+// conditional update, so concurrent redemptions can never oversell it.
+func (r *promotionRepository) Redeem(ctx context.Context, promotion *Promotion, quantity int, at time.Time) (*Promotion, error) {
+
+	filter := activeFilter(at)
+	filter["_id"] = promotion.ID
+
+	inc := bson.M{"sold": quantity}
+
+	if promotion.PromotionStock != nil {
+		filter["promotion_stock"] = bson.M{"$gte": quantity}
+		inc["promotion_stock"] = -quantity
+	} else {
+		filter["promotion_stock"] = nil
+	}
+
+	update := bson.M{
+		"$inc": inc,
+		"$set": bson.M{"updated_at": at},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var updated Promotion
+
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrPromotionUnavailable
+		}
+		return nil, err
+	}
+
+	return &updated, nil
+
+}
+
+func activeFilter(at time.Time) bson.M {
+	return bson.M{
+		"discount_enabled": true,
+		"start_at":         bson.M{"$lte": at},
+		"end_at":           bson.M{"$gt": at},
+		"$nor": bson.A{
+			bson.M{"promotion_stock": bson.M{"$lte": 0}},
+		},
+	}
+}
@@ -0,0 +1,43 @@
+package promotion
+
+import "time"
+
+type CreatePromotionRequest struct {
+	Name                 string    `json:"name"`
+	ProductID            string    `json:"product_id"`
+	FolderID             string    `json:"folder_id"`
+	DiscountType         string    `json:"discount_type"`
+	DiscountOff          float64   `json:"discount_off"`
+	DiscountPriceStore   *float64  `json:"discount_price_store"`
+	DiscountPriceService *float64  `json:"discount_price_service"`
+	PurchaseLimit        int       `json:"purchase_limit"`
+	PromotionStock       *int      `json:"promotion_stock"`
+	DiscountEnabled      *bool     `json:"discount_enabled"`
+	Priority             int       `json:"priority"`
+	StartAt              time.Time `json:"start_at"`
+	EndAt                time.Time `json:"end_at"`
+}
+
+type UpdatePromotionRequest struct {
+	Name                 string     `json:"name"`
+	DiscountType         string     `json:"discount_type"`
+	DiscountOff          *float64   `json:"discount_off"`
+	DiscountPriceStore   *float64   `json:"discount_price_store"`
+	DiscountPriceService *float64   `json:"discount_price_service"`
+	PurchaseLimit        *int       `json:"purchase_limit"`
+	PromotionStock       *int       `json:"promotion_stock"`
+	DiscountEnabled      *bool      `json:"discount_enabled"`
+	Priority             *int       `json:"priority"`
+	StartAt              *time.Time `json:"start_at"`
+	EndAt                *time.Time `json:"end_at"`
+}
+
+type RedeemPromotionRequest struct {
+	Quantity int `json:"quantity"`
+}
+
+type GetPromotionsRequest struct {
+	ProductID  string `form:"product_id"`
+	FolderID   string `form:"folder_id"`
+	ActiveOnly bool   `form:"active"`
+}
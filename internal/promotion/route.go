@@ -0,0 +1,20 @@
+package promotion
+
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, promotionHandler *PromotionHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	promotionGroup := r.Group("api/v1/promotions", middleware.Secured(verifier))
+	{
+		promotionGroup.GET("", authorizer.Require(auth.PromotionRead), promotionHandler.GetPromotions)
+		promotionGroup.GET("/:id", authorizer.Require(auth.PromotionRead), promotionHandler.GetPromotion)
+		promotionGroup.POST("", authorizer.Require(auth.PromotionWrite), promotionHandler.CreatePromotion)
+		promotionGroup.PUT("/:id", authorizer.Require(auth.PromotionWrite), promotionHandler.UpdatePromotion)
+		promotionGroup.DELETE("/:id", authorizer.Require(auth.PromotionDelete), promotionHandler.DeletePromotion)
+		promotionGroup.POST("/:id/redeem", authorizer.Require(auth.PromotionRedeem), promotionHandler.RedeemPromotion)
+	}
+}
@@ -0,0 +1,360 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-service/internal/shared/ports"
+	"product-service/pkg/mongotx"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PromotionService interface {
+	CreatePromotion(ctx context.Context, req *CreatePromotionRequest) (string, error)
+	GetPromotions(ctx context.Context, req *GetPromotionsRequest) ([]*Promotion, error)
+	GetPromotion(ctx context.Context, id string) (*Promotion, error)
+	UpdatePromotion(ctx context.Context, req *UpdatePromotionRequest, id string) error
+	DeletePromotion(ctx context.Context, id string) error
+	RedeemPromotion(ctx context.Context, req *RedeemPromotionRequest, id string, userID string) (*Promotion, error)
+	GetActivePromotions(ctx context.Context, productIDs []primitive.ObjectID, folderIDs []primitive.ObjectID, at time.Time) ([]*Promotion, error)
+}
+
+type promotionService struct {
+	promotionRepository  PromotionRepository
+	redemptionRepository RedemptionRepository
+	productRepository    ports.ProductRepository
+	folderRepository     ports.FolderRepository
+	transactions         mongotx.Runner
+}
+
+func NewPromotionService(promotionRepository PromotionRepository, redemptionRepository RedemptionRepository, productRepository ports.ProductRepository, folderRepository ports.FolderRepository, transactions mongotx.Runner) PromotionService {
+	return &promotionService{
+		promotionRepository:  promotionRepository,
+		redemptionRepository: redemptionRepository,
+		productRepository:    productRepository,
+		folderRepository:     folderRepository,
+		transactions:         transactions,
+	}
+}
+
+func (s *promotionService) CreatePromotion(ctx context.Context, req *CreatePromotionRequest) (string, error) {
+
+	if req.Name == "" {
+		return "", errors.New("name is required")
+	}
+
+	if (req.ProductID == "") == (req.FolderID == "") {
+		return "", errors.New("exactly one of product id or folder id is required")
+	}
+
+	promotion := &Promotion{
+		ID:                   primitive.NewObjectID(),
+		Name:                 req.Name,
+		DiscountType:         req.DiscountType,
+		DiscountOff:          req.DiscountOff,
+		DiscountPriceStore:   req.DiscountPriceStore,
+		DiscountPriceService: req.DiscountPriceService,
+		PurchaseLimit:        req.PurchaseLimit,
+		PromotionStock:       req.PromotionStock,
+		DiscountEnabled:      true,
+		Priority:             req.Priority,
+		StartAt:              req.StartAt,
+		EndAt:                req.EndAt,
+		CreatedAt:            time.Now(),
+		UpdatedAt:            time.Now(),
+	}
+
+	if req.DiscountEnabled != nil {
+		promotion.DiscountEnabled = *req.DiscountEnabled
+	}
+
+	if req.ProductID != "" {
+		productObjectID, err := primitive.ObjectIDFromHex(req.ProductID)
+		if err != nil {
+			return "", err
+		}
+
+		exists, err := s.productRepository.ProductExists(ctx, productObjectID)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return "", fmt.Errorf("product %s not found", req.ProductID)
+		}
+
+		promotion.ProductID = &productObjectID
+	}
+
+	if req.FolderID != "" {
+		folderObjectID, err := primitive.ObjectIDFromHex(req.FolderID)
+		if err != nil {
+			return "", err
+		}
+
+		if _, err := s.folderRepository.GetFolder(ctx, folderObjectID); err != nil {
+			return "", fmt.Errorf("folder %s not found", req.FolderID)
+		}
+
+		promotion.FolderID = &folderObjectID
+	}
+
+	if err := validatePromotion(promotion); err != nil {
+		return "", err
+	}
+
+	return s.promotionRepository.CreatePromotion(ctx, promotion)
+
+}
+
+func (s *promotionService) GetPromotions(ctx context.Context, req *GetPromotionsRequest) ([]*Promotion, error) {
+
+	filter := bson.M{}
+
+	if req.ActiveOnly {
+		filter = activeFilter(time.Now())
+	}
+
+	if req.ProductID != "" {
+		productObjectID, err := primitive.ObjectIDFromHex(req.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		filter["product_id"] = productObjectID
+	}
+
+	if req.FolderID != "" {
+		folderObjectID, err := primitive.ObjectIDFromHex(req.FolderID)
+		if err != nil {
+			return nil, err
+		}
+		filter["folder_id"] = folderObjectID
+	}
+
+	promotions, err := s.promotionRepository.GetPromotions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if promotions == nil {
+		return []*Promotion{}, nil
+	}
+
+	return promotions, nil
+
+}
+
+func (s *promotionService) GetPromotion(ctx context.Context, id string) (*Promotion, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.promotionRepository.GetPromotion(ctx, objectID)
+
+}
+
+func (s *promotionService) UpdatePromotion(ctx context.Context, req *UpdatePromotionRequest, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	promotion, err := s.promotionRepository.GetPromotion(ctx, objectID)
+	if err != nil {
+		return err
+	}
+
+	// Only the edited fields are written: sold and the remaining stock are
+	// changed concurrently by Redeem and must not be overwritten with the
+	// values read above.
+	changes := bson.M{}
+
+	if req.Name != "" {
+		promotion.Name = req.Name
+		changes["name"] = promotion.Name
+	}
+
+	if req.DiscountType != "" {
+		promotion.DiscountType = req.DiscountType
+		changes["discount_type"] = promotion.DiscountType
+	}
+
+	if req.DiscountOff != nil {
+		promotion.DiscountOff = *req.DiscountOff
+		changes["discount_off"] = promotion.DiscountOff
+	}
+
+	if req.DiscountPriceStore != nil {
+		promotion.DiscountPriceStore = req.DiscountPriceStore
+		changes["discount_price_store"] = promotion.DiscountPriceStore
+	}
+
+	if req.DiscountPriceService != nil {
+		promotion.DiscountPriceService = req.DiscountPriceService
+		changes["discount_price_service"] = promotion.DiscountPriceService
+	}
+
+	if req.PurchaseLimit != nil {
+		promotion.PurchaseLimit = *req.PurchaseLimit
+		changes["purchase_limit"] = promotion.PurchaseLimit
+	}
+
+	if req.PromotionStock != nil {
+		promotion.PromotionStock = req.PromotionStock
+		changes["promotion_stock"] = promotion.PromotionStock
+	}
+
+	if req.DiscountEnabled != nil {
+		promotion.DiscountEnabled = *req.DiscountEnabled
+		changes["discount_enabled"] = promotion.DiscountEnabled
+	}
+
+	if req.Priority != nil {
+		promotion.Priority = *req.Priority
+		changes["priority"] = promotion.Priority
+	}
+
+	if req.StartAt != nil {
+		promotion.StartAt = *req.StartAt
+		changes["start_at"] = promotion.StartAt
+	}
+
+	if req.EndAt != nil {
+		promotion.EndAt = *req.EndAt
+		changes["end_at"] = promotion.EndAt
+	}
+
+	if err := validatePromotion(promotion); err != nil {
+		return err
+	}
+
+	changes["updated_at"] = time.Now()
+
+	return s.promotionRepository.UpdatePromotion(ctx, objectID, changes)
+
+}
+
+func (s *promotionService) DeletePromotion(ctx context.Context, id string) error {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return err
+	}
+
+	return s.promotionRepository.DeletePromotion(ctx, objectID)
+
+}
+
+// RedeemPromotion consumes the promotion stock and, when the promotion has a
+// purchase limit, the user's share of it. Both run in one transaction when
+// the server supports it; otherwise a failed stock update releases the
+// user's reservation again.
+func (s *promotionService) RedeemPromotion(ctx context.Context, req *RedeemPromotionRequest, id string, userID string) (*Promotion, error) {
+
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if userID == "" {
+		return nil, errors.New("user id is required")
+	}
+
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+
+	promotion, err := s.promotionRepository.GetPromotion(ctx, objectID)
+	if err != nil {
+		return nil, err
+	}
+
+	limited := promotion.PurchaseLimit > 0
+
+	if limited && req.Quantity > promotion.PurchaseLimit {
+		return nil, fmt.Errorf("%w: quantity exceeds the limit of %d", ErrPurchaseLimitExceeded, promotion.PurchaseLimit)
+	}
+
+	var redeemed *Promotion
+
+	err = s.transactions.WithTransaction(ctx, func(ctx context.Context) error {
+
+		now := time.Now()
+
+		if limited {
+			err := s.redemptionRepository.Reserve(ctx, promotion.ID, userID, req.Quantity, promotion.PurchaseLimit, now)
+			if err != nil {
+				if errors.Is(err, ErrPurchaseLimitExceeded) {
+					return fmt.Errorf("%w: at most %d per user", ErrPurchaseLimitExceeded, promotion.PurchaseLimit)
+				}
+				return err
+			}
+		}
+
+		updated, err := s.promotionRepository.Redeem(ctx, promotion, req.Quantity, now)
+		if err != nil {
+			if limited {
+				if releaseErr := s.redemptionRepository.Release(ctx, promotion.ID, userID, req.Quantity); releaseErr != nil {
+					return errors.Join(err, releaseErr)
+				}
+			}
+			return err
+		}
+
+		redeemed = updated
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return redeemed, nil
+
+}
+
+func (s *promotionService) GetActivePromotions(ctx context.Context, productIDs []primitive.ObjectID, folderIDs []primitive.ObjectID, at time.Time) ([]*Promotion, error) {
+	return s.promotionRepository.GetActivePromotions(ctx, productIDs, folderIDs, at)
+}
+
+func validatePromotion(p *Promotion) error {
+
+	switch p.DiscountType {
+	case TypePercentage:
+		if p.DiscountOff <= 0 || p.DiscountOff > 100 {
+			return errors.New("discount off must be between 0 and 100")
+		}
+	case TypeFixed:
+		if p.DiscountPriceStore == nil && p.DiscountPriceService == nil {
+			return errors.New("discount price store or discount price service is required")
+		}
+		if (p.DiscountPriceStore != nil && *p.DiscountPriceStore < 0) || (p.DiscountPriceService != nil && *p.DiscountPriceService < 0) {
+			return errors.New("discount price must not be negative")
+		}
+	default:
+		return fmt.Errorf("unsupported discount type: %s", p.DiscountType)
+	}
+
+	if p.StartAt.IsZero() || p.EndAt.IsZero() {
+		return errors.New("start at and end at are required")
+	}
+
+	if !p.StartAt.Before(p.EndAt) {
+		return errors.New("start at must be before end at")
+	}
+
+	if p.PurchaseLimit < 0 {
+		return errors.New("purchase limit must not be negative")
+	}
+
+	if p.PromotionStock != nil && *p.PromotionStock < 0 {
+		return errors.New("promotion stock must not be negative")
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package promotion
+
+import (
+	"context"
+	"errors"
+	"product-service/pkg/mongotx"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type fakePromotionRepository struct {
+	PromotionRepository
+	promotion *Promotion
+	redeemErr error
+}
+
+func (r *fakePromotionRepository) GetPromotion(ctx context.Context, id primitive.ObjectID) (*Promotion, error) {
+	return r.promotion, nil
+}
+
+func (r *fakePromotionRepository) Redeem(ctx context.Context, promotion *Promotion, quantity int, at time.Time) (*Promotion, error) {
+
+	if r.redeemErr != nil {
+		return nil, r.redeemErr
+	}
+
+	updated := *promotion
+	updated.Sold += quantity
+
+	return &updated, nil
+}
+
+// fakeRedemptionRepository keeps the counters in memory with the same
+// limit check as the Mongo filter.
+type fakeRedemptionRepository struct {
+	counters map[string]int
+}
+
+func (r *fakeRedemptionRepository) EnsureIndexes(ctx context.Context) error {
+	return nil
+}
+
+func (r *fakeRedemptionRepository) Reserve(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int, limit int, at time.Time) error {
+
+	if r.counters[userID] > limit-quantity {
+		return ErrPurchaseLimitExceeded
+	}
+
+	r.counters[userID] += quantity
+
+	return nil
+}
+
+func (r *fakeRedemptionRepository) Release(ctx context.Context, promotionID primitive.ObjectID, userID string, quantity int) error {
+
+	r.counters[userID] -= quantity
+
+	return nil
+}
+
+func TestRedeemPromotionPurchaseLimit(t *testing.T) {
+
+	promotion := &Promotion{ID: primitive.NewObjectID(), PurchaseLimit: 3}
+
+	promotions := &fakePromotionRepository{promotion: promotion}
+	redemptions := &fakeRedemptionRepository{counters: map[string]int{}}
+	service := NewPromotionService(promotions, redemptions, nil, nil, mongotx.NewDirectRunner())
+
+	redeem := func(userID string, quantity int) error {
+		_, err := service.RedeemPromotion(context.Background(), &RedeemPromotionRequest{Quantity: quantity}, promotion.ID.Hex(), userID)
+		return err
+	}
+
+	if err := redeem("alice", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := redeem("alice", 2); !errors.Is(err, ErrPurchaseLimitExceeded) {
+		t.Errorf("second redemption error = %v, want ErrPurchaseLimitExceeded", err)
+	}
+	if err := redeem("alice", 1); err != nil {
+		t.Errorf("redemption up to the limit: %v", err)
+	}
+	if err := redeem("bob", 3); err != nil {
+		t.Errorf("other user's redemption: %v", err)
+	}
+	if err := redeem("bob", 4); !errors.Is(err, ErrPurchaseLimitExceeded) {
+		t.Errorf("single redemption over the limit error = %v, want ErrPurchaseLimitExceeded", err)
+	}
+
+	promotions.redeemErr = ErrPromotionUnavailable
+
+	if err := redeem("carol", 2); !errors.Is(err, ErrPromotionUnavailable) {
+		t.Errorf("redemption out of stock error = %v, want ErrPromotionUnavailable", err)
+	}
+	if redemptions.counters["carol"] != 0 {
+		t.Errorf("counter after a failed redemption = %d, want 0", redemptions.counters["carol"])
+	}
+}
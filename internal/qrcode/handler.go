@@ -0,0 +1,65 @@
+package qrcode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"product-service/helper"
+	"product-service/pkg/constants"
+	"product-service/pkg/qr"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QRCodeHandler struct {
+	registry *Registry
+}
+
+func NewQRCodeHandler(registry *Registry) *QRCodeHandler {
+	return &QRCodeHandler{
+		registry: registry,
+	}
+}
+
+func (h *QRCodeHandler) Resolve(ctx *gin.Context) {
+
+	var req ResolveRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	if req.Code == "" {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("code is required"), nil)
+		return
+	}
+
+	token, ok := ctx.Get(constants.Token)
+	if !ok {
+		helper.SendError(ctx, http.StatusBadRequest, errors.New("token not found"), nil)
+		return
+	}
+
+	c := context.WithValue(ctx, constants.TokenKey, token)
+
+	res, err := h.registry.Resolve(c, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrMalformedCode):
+			helper.SendError(ctx, http.StatusBadRequest, err, nil)
+		case errors.Is(err, ErrUnknownType):
+			helper.SendError(ctx, http.StatusUnprocessableEntity, err, nil)
+		case errors.Is(err, qr.ErrInvalidSignature), errors.Is(err, qr.ErrUnsignedPayload):
+			helper.SendError(ctx, http.StatusForbidden, err, nil)
+		case errors.Is(err, ErrCodeNotFound):
+			helper.SendError(ctx, http.StatusNotFound, err, nil)
+		default:
+			helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+		}
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "QR code resolved successfully", res)
+
+}
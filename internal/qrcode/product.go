@@ -0,0 +1,37 @@
+package qrcode
+
+import (
+	"context"
+	"errors"
+	"product-service/internal/product"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type productResolver struct {
+	productService product.ProductService
+}
+
+func NewProductResolver(productService product.ProductService) Resolver {
+	return &productResolver{
+		productService: productService,
+	}
+}
+
+func (r *productResolver) Resolve(ctx context.Context, value string) (interface{}, error) {
+
+	if !primitive.IsValidObjectID(value) {
+		return nil, ErrMalformedCode
+	}
+
+	res, err := r.productService.GetProduct(ctx, value)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrCodeNotFound
+		}
+		return nil, err
+	}
+
+	return res, nil
+}
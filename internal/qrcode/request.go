@@ -0,0 +1,5 @@
+package qrcode
+
+type ResolveRequest struct {
+	Code string `json:"code"`
+}
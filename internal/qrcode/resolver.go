@@ -0,0 +1,84 @@
+package qrcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"product-service/pkg/qr"
+)
+
+var (
+	ErrMalformedCode = errors.New("malformed qr code")
+	ErrUnknownType   = errors.New("unsupported qr code type")
+	ErrCodeNotFound  = errors.New("qr code refers to a record that does not exist")
+)
+
+// Resolver loads the record a scanned code points at. value is the part of
+// the payload after the type prefix.
+type Resolver interface {
+	Resolve(ctx context.Context, value string) (interface{}, error)
+}
+
+// Registry dispatches scanned codes to the resolver registered for their
+// type, so new SENBOX.ORG[<TYPE>] codes only need a new Resolver.
+type Registry struct {
+	mu        sync.RWMutex
+	resolvers map[string]Resolver
+	signer    *qr.Signer
+}
+
+func NewRegistry(signer *qr.Signer) *Registry {
+	return &Registry{
+		resolvers: make(map[string]Resolver),
+		signer:    signer,
+	}
+}
+
+func (r *Registry) Register(kind string, resolver Resolver) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.resolvers[strings.ToUpper(kind)] = resolver
+}
+
+func (r *Registry) lookup(kind string) (Resolver, bool) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	resolver, ok := r.resolvers[kind]
+
+	return resolver, ok
+}
+
+func (r *Registry) Resolve(ctx context.Context, code string) (*ResolveResponse, error) {
+
+	payload, err := qr.ParsePayload(strings.TrimSpace(code))
+	if err != nil {
+		return nil, ErrMalformedCode
+	}
+
+	if err := r.signer.Verify(payload); err != nil {
+		return nil, err
+	}
+
+	resolver, ok := r.lookup(payload.Type)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownType, payload.Type)
+	}
+
+	data, err := resolver.Resolve(ctx, payload.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResolveResponse{
+		Type: payload.Type,
+		ID:   payload.Value,
+		Data: data,
+	}, nil
+}
@@ -0,0 +1,7 @@
+package qrcode
+
+type ResolveResponse struct {
+	Type string      `json:"type"`
+	ID   string      `json:"id"`
+	Data interface{} `json:"data"`
+}
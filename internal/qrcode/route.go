@@ -0,0 +1,15 @@
+package qrcode
+
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, qrcodeHandler *QRCodeHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	qrcodeGroup := r.Group("api/v1/qrcode", middleware.Secured(verifier))
+	{
+		qrcodeGroup.POST("/resolve", authorizer.Require(auth.ProductRead), qrcodeHandler.Resolve)
+	}
+}
@@ -9,4 +9,6 @@ import (
 
 type FolderRepository interface {
 	GetFolder(ctx context.Context, id primitive.ObjectID) (*folder.Folder, error)
-}
\ No newline at end of file
+	GetFoldersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*folder.Folder, error)
+	GetDescendantIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error)
+}
@@ -1 +1,11 @@
-package ports
\ No newline at end of file
+package ports
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ProductRepository interface {
+	ProductExists(ctx context.Context, id primitive.ObjectID) (bool, error)
+}
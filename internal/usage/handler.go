@@ -0,0 +1,96 @@
+package usage
+
+import (
+	"errors"
+	"net/http"
+	"product-service/helper"
+	"product-service/pkg/constants"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type UsageHandler struct {
+	usageService UsageService
+}
+
+func NewUsageHandler(usageService UsageService) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+	}
+}
+
+func (h *UsageHandler) StartUsage(ctx *gin.Context) {
+
+	productID := ctx.Param("product_id")
+	userID := ctx.GetString(constants.UserID)
+
+	if userID == "" {
+		helper.SendError(ctx, http.StatusUnauthorized, errors.New("user id not found in token"), nil)
+		return
+	}
+
+	res, err := h.usageService.StartUsage(ctx, userID, productID)
+	if err != nil {
+		sendUsageError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Usage started successfully", res)
+
+}
+
+func (h *UsageHandler) StopUsage(ctx *gin.Context) {
+
+	productID := ctx.Param("product_id")
+	userID := ctx.GetString(constants.UserID)
+
+	if userID == "" {
+		helper.SendError(ctx, http.StatusUnauthorized, errors.New("user id not found in token"), nil)
+		return
+	}
+
+	res, err := h.usageService.StopUsage(ctx, userID, productID)
+	if err != nil {
+		sendUsageError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Usage stopped successfully", res)
+
+}
+
+func (h *UsageHandler) GetRemainingUses(ctx *gin.Context) {
+
+	productID := ctx.Param("product_id")
+	userID := ctx.GetString(constants.UserID)
+
+	if userID == "" {
+		helper.SendError(ctx, http.StatusUnauthorized, errors.New("user id not found in token"), nil)
+		return
+	}
+
+	res, err := h.usageService.GetRemainingUses(ctx, userID, productID)
+	if err != nil {
+		sendUsageError(ctx, err)
+		return
+	}
+
+	helper.SendSuccess(ctx, http.StatusOK, "Remaining uses retrieved successfully", res)
+
+}
+
+func sendUsageError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		helper.SendError(ctx, http.StatusNotFound, errors.New("product not found"), nil)
+	case errors.Is(err, ErrNoActiveSession):
+		helper.SendError(ctx, http.StatusNotFound, err, nil)
+	case errors.Is(err, ErrUsageNotConfigured):
+		helper.SendError(ctx, http.StatusBadRequest, err, nil)
+	case errors.Is(err, ErrNoRemainingUses), errors.Is(err, ErrSessionActive), errors.Is(err, ErrBelowMinimumUsage):
+		helper.SendError(ctx, http.StatusConflict, err, nil)
+	default:
+		helper.SendError(ctx, http.StatusInternalServerError, err, nil)
+	}
+}
@@ -0,0 +1,23 @@
+package usage
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	StatusActive    = "active"
+	StatusCompleted = "completed"
+	StatusExpired   = "expired"
+)
+
+type UsageSession struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id"`
+	UserID          string             `json:"user_id" bson:"user_id"`
+	ProductID       primitive.ObjectID `json:"product_id" bson:"product_id"`
+	Status          string             `json:"status" bson:"status"`
+	StartedAt       time.Time          `json:"started_at" bson:"started_at"`
+	EndedAt         *time.Time         `json:"ended_at" bson:"ended_at"`
+	DurationSeconds int64              `json:"duration_seconds" bson:"duration_seconds"`
+}
@@ -0,0 +1,113 @@
+package usage
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type UsageRepository interface {
+	EnsureIndexes(ctx context.Context) error
+	CreateSession(ctx context.Context, session *UsageSession) (string, error)
+	GetActiveSession(ctx context.Context, userID string, productID primitive.ObjectID) (*UsageSession, error)
+	CloseSession(ctx context.Context, session *UsageSession) error
+	CountUsedSessions(ctx context.Context, userID string, productID primitive.ObjectID) (int64, error)
+}
+
+type usageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewUsageRepository(collection *mongo.Collection) UsageRepository {
+	return &usageRepository{
+		collection: collection,
+	}
+}
+
+// EnsureIndexes creates a partial unique index that allows a single active
+// session per user and product, which closes the race between two starts.
+func (r *usageRepository) EnsureIndexes(ctx context.Context) error {
+
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}},
+			Options: options.Index().
+				SetName("one_active_session").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": StatusActive}),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "product_id", Value: 1}, {Key: "status", Value: 1}},
+		},
+	})
+
+	return err
+}
+
+func (r *usageRepository) CreateSession(ctx context.Context, session *UsageSession) (string, error) {
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return "", err
+	}
+
+	return result.InsertedID.(primitive.ObjectID).Hex(), nil
+
+}
+
+func (r *usageRepository) GetActiveSession(ctx context.Context, userID string, productID primitive.ObjectID) (*UsageSession, error) {
+
+	var session UsageSession
+
+	filter := bson.M{
+		"user_id":    userID,
+		"product_id": productID,
+		"status":     StatusActive,
+	}
+
+	err := r.collection.FindOne(ctx, filter).Decode(&session)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+
+}
+
+func (r *usageRepository) CloseSession(ctx context.Context, session *UsageSession) error {
+
+	filter := bson.M{"_id": session.ID, "status": StatusActive}
+
+	update := bson.M{"$set": bson.M{
+		"status":           session.Status,
+		"ended_at":         session.EndedAt,
+		"duration_seconds": session.DurationSeconds,
+	}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrNoActiveSession
+	}
+
+	return nil
+
+}
+
+func (r *usageRepository) CountUsedSessions(ctx context.Context, userID string, productID primitive.ObjectID) (int64, error) {
+
+	filter := bson.M{
+		"user_id":    userID,
+		"product_id": productID,
+		"status":     bson.M{"$in": bson.A{StatusCompleted, StatusExpired}},
+	}
+
+	return r.collection.CountDocuments(ctx, filter)
+
+}
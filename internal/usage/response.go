@@ -0,0 +1,10 @@
+package usage
+
+type RemainingUsesResponse struct {
+	ProductID     string        `json:"product_id"`
+	UserID        string        `json:"user_id"`
+	NumberOfUses  int           `json:"number_of_uses"`
+	Used          int64         `json:"used"`
+	Remaining     *int64        `json:"remaining"`
+	ActiveSession *UsageSession `json:"active_session"`
+}
@@ -0,0 +1,17 @@
+package usage
+
+import (
+	"product-service/internal/middleware"
+	"product-service/pkg/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(r *gin.Engine, usageHandler *UsageHandler, verifier auth.Verifier, authorizer *middleware.Authorizer) {
+	usageGroup := r.Group("api/v1/usage", middleware.Secured(verifier))
+	{
+		usageGroup.POST("/products/:product_id/start", authorizer.Require(auth.UsageWrite), usageHandler.StartUsage)
+		usageGroup.POST("/products/:product_id/stop", authorizer.Require(auth.UsageWrite), usageHandler.StopUsage)
+		usageGroup.GET("/products/:product_id/remaining", authorizer.Require(auth.UsageRead), usageHandler.GetRemainingUses)
+	}
+}
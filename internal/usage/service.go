@@ -0,0 +1,214 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"product-service/internal/product"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrUsageNotConfigured = errors.New("product has no usage config")
+	ErrNoRemainingUses    = errors.New("no remaining uses for this product")
+	ErrSessionActive      = errors.New("a usage session is already active for this product")
+	ErrNoActiveSession    = errors.New("no active usage session for this product")
+	ErrBelowMinimumUsage  = errors.New("minimum usage time has not been reached")
+)
+
+type UsageService interface {
+	StartUsage(ctx context.Context, userID string, productID string) (*UsageSession, error)
+	StopUsage(ctx context.Context, userID string, productID string) (*UsageSession, error)
+	GetRemainingUses(ctx context.Context, userID string, productID string) (*RemainingUsesResponse, error)
+}
+
+type usageService struct {
+	usageRepository   UsageRepository
+	productRepository product.ProductRepository
+}
+
+func NewUsageService(usageRepository UsageRepository, productRepository product.ProductRepository) UsageService {
+	return &usageService{
+		usageRepository:   usageRepository,
+		productRepository: productRepository,
+	}
+}
+
+func (s *usageService) StartUsage(ctx context.Context, userID string, productID string) (*UsageSession, error) {
+
+	productObjectID, cfg, err := s.usageConfig(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	active, err := s.activeSession(ctx, userID, productObjectID, cfg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if active != nil {
+		return nil, ErrSessionActive
+	}
+
+	if cfg.NumberOfUses > 0 {
+		used, err := s.usageRepository.CountUsedSessions(ctx, userID, productObjectID)
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(cfg.NumberOfUses) {
+			return nil, ErrNoRemainingUses
+		}
+	}
+
+	session := &UsageSession{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		ProductID: productObjectID,
+		Status:    StatusActive,
+		StartedAt: now,
+	}
+
+	if _, err := s.usageRepository.CreateSession(ctx, session); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrSessionActive
+		}
+		return nil, err
+	}
+
+	return session, nil
+
+}
+
+func (s *usageService) StopUsage(ctx context.Context, userID string, productID string) (*UsageSession, error) {
+
+	productObjectID, cfg, err := s.usageConfig(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	session, err := s.usageRepository.GetActiveSession(ctx, userID, productObjectID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, ErrNoActiveSession
+		}
+		return nil, err
+	}
+
+	minimum := time.Duration(cfg.MinimumUsageTime) * time.Minute
+	if elapsed := now.Sub(session.StartedAt); elapsed < minimum {
+		return nil, fmt.Errorf("%w: %s left", ErrBelowMinimumUsage, (minimum - elapsed).Round(time.Second))
+	}
+
+	if err := s.closeSession(ctx, session, cfg, now); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+
+}
+
+func (s *usageService) GetRemainingUses(ctx context.Context, userID string, productID string) (*RemainingUsesResponse, error) {
+
+	productObjectID, cfg, err := s.usageConfig(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := s.activeSession(ctx, userID, productObjectID, cfg, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := s.usageRepository.CountUsedSessions(ctx, userID, productObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RemainingUsesResponse{
+		ProductID:     productID,
+		UserID:        userID,
+		NumberOfUses:  cfg.NumberOfUses,
+		Used:          used,
+		ActiveSession: active,
+	}
+
+	if cfg.NumberOfUses > 0 {
+		remaining := max(int64(cfg.NumberOfUses)-used, 0)
+		res.Remaining = &remaining
+	}
+
+	return res, nil
+
+}
+
+func (s *usageService) usageConfig(ctx context.Context, userID string, productID string) (primitive.ObjectID, *product.UsageConfig, error) {
+
+	if userID == "" {
+		return primitive.NilObjectID, nil, errors.New("user id is required")
+	}
+
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return primitive.NilObjectID, nil, err
+	}
+
+	p, err := s.productRepository.GetProduct(ctx, productObjectID)
+	if err != nil {
+		return primitive.NilObjectID, nil, err
+	}
+
+	if p.UsageConfig == nil {
+		return primitive.NilObjectID, nil, ErrUsageNotConfigured
+	}
+
+	return productObjectID, p.UsageConfig, nil
+}
+
+// activeSession returns the user's running session, closing it as expired
+// first when it has outlived the maximum usage time.
+func (s *usageService) activeSession(ctx context.Context, userID string, productID primitive.ObjectID, cfg *product.UsageConfig, now time.Time) (*UsageSession, error) {
+
+	session, err := s.usageRepository.GetActiveSession(ctx, userID, productID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	maximum := time.Duration(cfg.MaximumUsageTime) * time.Minute
+	if maximum > 0 && now.Sub(session.StartedAt) > maximum {
+		if err := s.closeSession(ctx, session, cfg, now); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	return session, nil
+}
+
+// closeSession ends a session. Sessions that ran past the maximum usage time
+// are recorded as expired with their duration capped at the maximum.
+func (s *usageService) closeSession(ctx context.Context, session *UsageSession, cfg *product.UsageConfig, now time.Time) error {
+
+	end := now
+	session.Status = StatusCompleted
+
+	maximum := time.Duration(cfg.MaximumUsageTime) * time.Minute
+	if maximum > 0 && now.Sub(session.StartedAt) > maximum {
+		end = session.StartedAt.Add(maximum)
+		session.Status = StatusExpired
+	}
+
+	session.EndedAt = &end
+	session.DurationSeconds = int64(end.Sub(session.StartedAt).Seconds())
+
+	return s.usageRepository.CloseSession(ctx, session)
+}
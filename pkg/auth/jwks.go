@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+)
+
+const jwksReloadInterval = 5 * time.Second
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// jwksFile holds the keys of a local JWKS file. The file's modtime is
+// checked at most every reloadInterval, whatever kid a token names, and the
+// key set is replaced when it changed: new keys are picked up and removed
+// ones stop being accepted without a restart.
+type jwksFile struct {
+	path           string
+	reloadInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func newJWKSFile(path string) (*jwksFile, error) {
+
+	f := &jwksFile{path: path, reloadInterval: jwksReloadInterval}
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// key returns the key for kid. When the file cannot be reloaded the keys
+// of the last successful load stay in use.
+func (f *jwksFile) key(kid string) (interface{}, bool) {
+
+	f.mu.RLock()
+	due := time.Since(f.checkedAt) >= f.reloadInterval
+	f.mu.RUnlock()
+
+	if due {
+		if err := f.load(); err != nil {
+			log.Printf("Error reloading JWKS file: %v", err)
+		}
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	key, ok := f.keys[kid]
+	return key, ok
+}
+
+func (f *jwksFile) load() error {
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.checkedAt = time.Now()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading JWKS file: %v", err)
+	}
+
+	if f.keys != nil && info.ModTime().Equal(f.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("error reading JWKS file: %v", err)
+	}
+
+	var set jsonWebKeySet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("error parsing JWKS file: %v", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			return fmt.Errorf("error parsing JWKS key %q: %v", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	f.keys = keys
+	f.modTime = info.ModTime()
+
+	return nil
+}
+
+func (k *jsonWebKey) publicKey() (interface{}, error) {
+
+	switch k.Kty {
+	case "oct":
+		return decodeSegment(k.K)
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeSegment(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+
+	data, err := decodeSegment(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
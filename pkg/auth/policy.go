@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	ProductRead   = "product:read"
+	ProductWrite  = "product:write"
+	ProductDelete = "product:delete"
+	FolderRead    = "folder:read"
+	FolderWrite   = "folder:write"
+	FolderDelete  = "folder:delete"
+
+	PromotionRead   = "promotion:read"
+	PromotionWrite  = "promotion:write"
+	PromotionDelete = "promotion:delete"
+	PromotionRedeem = "promotion:redeem"
+
+	UsageRead  = "usage:read"
+	UsageWrite = "usage:write"
+
+	OutboxRead   = "outbox:read"
+	OutboxReplay = "outbox:replay"
+)
+
+// anyRole lists permissions granted to every authenticated caller.
+const anyRole = "*"
+
+// Policy maps role names to the permissions they grant. A permission entry of
+// "*" grants everything and "product:*" grants every product permission.
+type Policy struct {
+	Roles map[string][]string `json:"roles"`
+}
+
+func LoadPolicy(path string) (*Policy, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy file: %v", err)
+	}
+
+	return ParsePolicy(data)
+}
+
+func ParsePolicy(data []byte) (*Policy, error) {
+
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("error parsing policy: %v", err)
+	}
+
+	normalized := make(map[string][]string, len(policy.Roles))
+	for role, permissions := range policy.Roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		normalized[role] = append(normalized[role], permissions...)
+	}
+	policy.Roles = normalized
+
+	return &policy, nil
+}
+
+func (p *Policy) Allows(roles []string, permission string) bool {
+
+	if grants(p.Roles[anyRole], permission) {
+		return true
+	}
+
+	for _, role := range roles {
+		if grants(p.Roles[strings.ToLower(strings.TrimSpace(role))], permission) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func grants(permissions []string, permission string) bool {
+
+	resource, _, _ := strings.Cut(permission, ":")
+
+	for _, p := range permissions {
+		if p == "*" || p == permission || p == resource+":*" {
+			return true
+		}
+	}
+
+	return false
+}
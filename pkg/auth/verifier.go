@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"product-service/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNoKeysConfigured = errors.New("no JWT verification keys configured")
+	ErrUnknownKey       = errors.New("no verification key matches the token")
+)
+
+var supportedMethods = []string{
+	"HS256", "HS384", "HS512",
+	"RS256", "RS384", "RS512",
+	"PS256", "PS384", "PS512",
+	"ES256", "ES384", "ES512",
+}
+
+type Verifier interface {
+	Verify(tokenString string) (jwt.MapClaims, error)
+}
+
+type verifier struct {
+	staticKey interface{}
+	jwks      *jwksFile
+	parser    *jwt.Parser
+}
+
+// NewVerifier builds a verifier from the JWT config. A static key comes from
+// either the HMAC secret or a PEM public key file; a JWKS file adds keys that
+// are selected by the token's kid header and reloaded when the file changes.
+func NewVerifier(cfg config.JWTConfig) (Verifier, error) {
+
+	v := &verifier{}
+
+	switch {
+	case cfg.Secret != "" && cfg.PublicKeyFile != "":
+		return nil, errors.New("JWT_SECRET and JWT_PUBLIC_KEY_FILE are mutually exclusive")
+	case cfg.Secret != "":
+		v.staticKey = []byte(cfg.Secret)
+	case cfg.PublicKeyFile != "":
+		key, err := loadPublicKey(cfg.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		v.staticKey = key
+	}
+
+	if cfg.JWKSFile != "" {
+		jwks, err := newJWKSFile(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.jwks = jwks
+	}
+
+	if v.staticKey == nil && v.jwks == nil {
+		return nil, ErrNoKeysConfigured
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(supportedMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+func (v *verifier) Verify(tokenString string) (jwt.MapClaims, error) {
+
+	claims := jwt.MapClaims{}
+
+	_, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc)
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+func (v *verifier) keyFunc(token *jwt.Token) (interface{}, error) {
+
+	kid, _ := token.Header["kid"].(string)
+
+	if kid != "" && v.jwks != nil {
+		if key, ok := v.jwks.key(kid); ok {
+			return checkKeyType(token, key)
+		}
+	}
+
+	if v.staticKey != nil {
+		return checkKeyType(token, v.staticKey)
+	}
+
+	return nil, fmt.Errorf("%w: kid %q", ErrUnknownKey, kid)
+}
+
+// checkKeyType makes sure the signing method in the token header fits the key,
+// so an RSA public key can never be used as an HMAC secret.
+func checkKeyType(token *jwt.Token, key interface{}) (interface{}, error) {
+
+	var ok bool
+
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		_, ok = key.([]byte)
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		_, ok = key.(*rsa.PublicKey)
+	case *jwt.SigningMethodECDSA:
+		_, ok = key.(*ecdsa.PublicKey)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: signing method %s", ErrUnknownKey, token.Method.Alg())
+	}
+
+	return key, nil
+}
+
+func loadPublicKey(path string) (interface{}, error) {
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading public key: %v", err)
+	}
+
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("public key %s is neither an RSA nor an ECDSA PEM key", path)
+}
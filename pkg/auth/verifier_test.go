@@ -0,0 +1,402 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"product-service/config"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret-that-is-long-enough"
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func generateECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key
+}
+
+func writePublicKeyPEM(t *testing.T, public interface{}) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "public.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func encodeBigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		N:   encodeBigInt(key.N),
+		E:   encodeBigInt(big.NewInt(int64(key.E))),
+	}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: "P-256",
+		X:   encodeBigInt(key.X),
+		Y:   encodeBigInt(key.Y),
+	}
+}
+
+func octJWK(kid string, secret []byte) jsonWebKey {
+	return jsonWebKey{
+		Kty: "oct",
+		Kid: kid,
+		K:   base64.RawURLEncoding.EncodeToString(secret),
+	}
+}
+
+// writeJWKS writes the key set and moves the modtime forward, so a rewrite
+// within the file system's timestamp resolution is still seen as a change.
+func writeJWKS(t *testing.T, path string, keys ...jsonWebKey) {
+	t.Helper()
+
+	data, err := json.Marshal(jsonWebKeySet{Keys: keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil && !info.ModTime().Before(modTime) {
+		modTime = info.ModTime().Add(time.Second)
+	}
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub": "user-1",
+		"iss": "https://issuer.test",
+		"aud": "product-service",
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"nbf": time.Now().Add(-time.Minute).Unix(),
+	}
+}
+
+func withClaims(changes jwt.MapClaims) jwt.MapClaims {
+
+	claims := validClaims()
+	for name, value := range changes {
+		if value == nil {
+			delete(claims, name)
+			continue
+		}
+		claims[name] = value
+	}
+
+	return claims
+}
+
+func TestVerifierClaims(t *testing.T) {
+
+	v, err := NewVerifier(config.JWTConfig{
+		Secret:   testSecret,
+		Issuer:   "https://issuer.test",
+		Audience: "product-service",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		claims  jwt.MapClaims
+		wantErr error
+	}{
+		{name: "valid", claims: validClaims()},
+		{name: "expired", claims: withClaims(jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}), wantErr: jwt.ErrTokenExpired},
+		{name: "missing exp", claims: withClaims(jwt.MapClaims{"exp": nil}), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "not yet valid", claims: withClaims(jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()}), wantErr: jwt.ErrTokenNotValidYet},
+		{name: "wrong issuer", claims: withClaims(jwt.MapClaims{"iss": "https://other.test"}), wantErr: jwt.ErrTokenInvalidIssuer},
+		{name: "missing issuer", claims: withClaims(jwt.MapClaims{"iss": nil}), wantErr: jwt.ErrTokenRequiredClaimMissing},
+		{name: "wrong audience", claims: withClaims(jwt.MapClaims{"aud": "other-service"}), wantErr: jwt.ErrTokenInvalidAudience},
+		{name: "audience in list", claims: withClaims(jwt.MapClaims{"aud": []string{"other-service", "product-service"}})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", tt.claims))
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierLeeway(t *testing.T) {
+
+	v, err := NewVerifier(config.JWTConfig{Secret: testSecret, Leeway: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := jwt.MapClaims{"exp": time.Now().Add(-30 * time.Second).Unix()}
+
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testSecret), "", claims)); err != nil {
+		t.Fatalf("Verify() error = %v, want token within leeway accepted", err)
+	}
+}
+
+func TestVerifierStaticKeys(t *testing.T) {
+
+	rsaKey := generateRSAKey(t)
+	ecKey := generateECKey(t)
+	otherRSAKey := generateRSAKey(t)
+
+	rsaPEM := writePublicKeyPEM(t, &rsaKey.PublicKey)
+	ecPEM := writePublicKeyPEM(t, &ecKey.PublicKey)
+
+	rsaPEMBytes, err := os.ReadFile(rsaPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.JWTConfig
+		method  jwt.SigningMethod
+		key     interface{}
+		wantErr error
+	}{
+		{name: "HS256", cfg: config.JWTConfig{Secret: testSecret}, method: jwt.SigningMethodHS256, key: []byte(testSecret)},
+		{name: "HS512", cfg: config.JWTConfig{Secret: testSecret}, method: jwt.SigningMethodHS512, key: []byte(testSecret)},
+		{name: "HMAC wrong secret", cfg: config.JWTConfig{Secret: testSecret}, method: jwt.SigningMethodHS256, key: []byte("another-secret"), wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "RS256", cfg: config.JWTConfig{PublicKeyFile: rsaPEM}, method: jwt.SigningMethodRS256, key: rsaKey},
+		{name: "PS256", cfg: config.JWTConfig{PublicKeyFile: rsaPEM}, method: jwt.SigningMethodPS256, key: rsaKey},
+		{name: "RSA wrong key", cfg: config.JWTConfig{PublicKeyFile: rsaPEM}, method: jwt.SigningMethodRS256, key: otherRSAKey, wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "ES256", cfg: config.JWTConfig{PublicKeyFile: ecPEM}, method: jwt.SigningMethodES256, key: ecKey},
+		{name: "HS256 signed with RSA public key", cfg: config.JWTConfig{PublicKeyFile: rsaPEM}, method: jwt.SigningMethodHS256, key: rsaPEMBytes, wantErr: ErrUnknownKey},
+		{name: "ES256 against RSA key", cfg: config.JWTConfig{PublicKeyFile: rsaPEM}, method: jwt.SigningMethodES256, key: ecKey, wantErr: ErrUnknownKey},
+		{name: "RS256 against ECDSA key", cfg: config.JWTConfig{PublicKeyFile: ecPEM}, method: jwt.SigningMethodRS256, key: rsaKey, wantErr: ErrUnknownKey},
+		{name: "RS256 against HMAC secret", cfg: config.JWTConfig{Secret: testSecret}, method: jwt.SigningMethodRS256, key: rsaKey, wantErr: ErrUnknownKey},
+		{name: "none", cfg: config.JWTConfig{Secret: testSecret}, method: jwt.SigningMethodNone, key: jwt.UnsafeAllowNoneSignatureType, wantErr: jwt.ErrTokenSignatureInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			v, err := NewVerifier(tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = v.Verify(sign(t, tt.method, tt.key, "", validClaims()))
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierJWKS(t *testing.T) {
+
+	rsaKey := generateRSAKey(t)
+	ecKey := generateECKey(t)
+	secret := []byte(testSecret)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("rsa-1", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey), octJWK("hmac-1", secret))
+
+	v, err := NewVerifier(config.JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		method  jwt.SigningMethod
+		key     interface{}
+		kid     string
+		wantErr error
+	}{
+		{name: "RSA kid", method: jwt.SigningMethodRS256, key: rsaKey, kid: "rsa-1"},
+		{name: "ECDSA kid", method: jwt.SigningMethodES256, key: ecKey, kid: "ec-1"},
+		{name: "HMAC kid", method: jwt.SigningMethodHS256, key: secret, kid: "hmac-1"},
+		{name: "unknown kid", method: jwt.SigningMethodRS256, key: rsaKey, kid: "rsa-9", wantErr: ErrUnknownKey},
+		{name: "no kid", method: jwt.SigningMethodRS256, key: rsaKey, wantErr: ErrUnknownKey},
+		{name: "kid of another key", method: jwt.SigningMethodRS256, key: generateRSAKey(t), kid: "rsa-1", wantErr: jwt.ErrTokenSignatureInvalid},
+		{name: "alg does not match kid", method: jwt.SigningMethodES256, key: ecKey, kid: "rsa-1", wantErr: ErrUnknownKey},
+		{name: "HMAC with RSA kid", method: jwt.SigningMethodHS256, key: secret, kid: "rsa-1", wantErr: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, err := v.Verify(sign(t, tt.method, tt.key, tt.kid, validClaims()))
+
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("Verify() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifierJWKSRotation(t *testing.T) {
+
+	oldKey := generateRSAKey(t)
+	newKey := generateRSAKey(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("old", &oldKey.PublicKey))
+
+	v, err := NewVerifier(config.JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.(*verifier).jwks.reloadInterval = 0
+
+	oldToken := sign(t, jwt.SigningMethodRS256, oldKey, "old", validClaims())
+	newToken := sign(t, jwt.SigningMethodRS256, newKey, "new", validClaims())
+
+	steps := []struct {
+		name    string
+		keys    []jsonWebKey
+		token   string
+		wantErr error
+	}{
+		{name: "old key accepted", token: oldToken},
+		{name: "new key not yet published", token: newToken, wantErr: ErrUnknownKey},
+		{name: "new key added", keys: []jsonWebKey{rsaJWK("old", &oldKey.PublicKey), rsaJWK("new", &newKey.PublicKey)}, token: newToken},
+		{name: "old key still accepted during rotation", token: oldToken},
+		{name: "old key removed", keys: []jsonWebKey{rsaJWK("new", &newKey.PublicKey)}, token: oldToken, wantErr: ErrUnknownKey},
+		{name: "new key still accepted", token: newToken},
+	}
+
+	for _, step := range steps {
+
+		if step.keys != nil {
+			writeJWKS(t, path, step.keys...)
+		}
+
+		_, err := v.Verify(step.token)
+
+		if step.wantErr == nil && err != nil {
+			t.Fatalf("%s: Verify() error = %v, want nil", step.name, err)
+		}
+		if step.wantErr != nil && !errors.Is(err, step.wantErr) {
+			t.Fatalf("%s: Verify() error = %v, want %v", step.name, err, step.wantErr)
+		}
+	}
+}
+
+func TestVerifierJWKSKeepsKeysOnBrokenFile(t *testing.T) {
+
+	key := generateRSAKey(t)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, rsaJWK("k1", &key.PublicKey))
+
+	v, err := NewVerifier(config.JWTConfig{JWKSFile: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.(*verifier).jwks.reloadInterval = 0
+
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := v.Verify(sign(t, jwt.SigningMethodRS256, key, "k1", validClaims())); err != nil {
+		t.Fatalf("Verify() error = %v, want the last loaded keys kept", err)
+	}
+}
+
+func TestNewVerifierConfig(t *testing.T) {
+
+	if _, err := NewVerifier(config.JWTConfig{}); !errors.Is(err, ErrNoKeysConfigured) {
+		t.Fatalf("NewVerifier() error = %v, want %v", err, ErrNoKeysConfigured)
+	}
+
+	path := writePublicKeyPEM(t, &generateRSAKey(t).PublicKey)
+
+	if _, err := NewVerifier(config.JWTConfig{Secret: testSecret, PublicKeyFile: path}); err == nil {
+		t.Fatal("NewVerifier() accepted both a secret and a public key file")
+	}
+}
@@ -106,6 +106,9 @@ const (
 	MaximumUsageTime = "maximum_usage_time"
 
 	UserID = "user_id"
+	Claims = "claims"
+	Role   = "role"
+	Roles  = "roles"
 )
 
 type contextKey string
@@ -0,0 +1,269 @@
+// Package elastic is a minimal Elasticsearch client covering the handful of
+// REST endpoints the service needs: index management, bulk writes, delete by
+// query and search.
+package elastic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Error is a failed Elasticsearch request or bulk item.
+type Error struct {
+	Status int
+	Type   string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	if e.Type == "" {
+		return fmt.Sprintf("elasticsearch: status %d", e.Status)
+	}
+	return fmt.Sprintf("elasticsearch: status %d: %s: %s", e.Status, e.Type, e.Reason)
+}
+
+type Client struct {
+	baseUrl    string
+	username   string
+	password   string
+	httpClient *http.Client
+}
+
+func NewClient(baseUrl, username, password string) *Client {
+	return &Client{
+		baseUrl:    strings.TrimRight(baseUrl, "/"),
+		username:   username,
+		password:   password,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// BulkAction is one index or delete line of a bulk request. Doc is ignored
+// for deletes.
+type BulkAction struct {
+	Delete bool
+	ID     string
+	Doc    interface{}
+}
+
+type SearchResult struct {
+	Hits struct {
+		Total struct {
+			Value int64 `json:"value"`
+		} `json:"total"`
+		Hits []SearchHit `json:"hits"`
+	} `json:"hits"`
+}
+
+type SearchHit struct {
+	ID        string              `json:"_id"`
+	Score     float64             `json:"_score"`
+	Source    json.RawMessage     `json:"_source"`
+	Highlight map[string][]string `json:"highlight"`
+}
+
+func (c *Client) IndexExists(ctx context.Context, index string) (bool, error) {
+
+	status, _, err := c.do(ctx, http.MethodHead, "/"+url.PathEscape(index), "", nil)
+	if err != nil {
+		return false, err
+	}
+
+	switch status {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, &Error{Status: status}
+	}
+}
+
+// CreateIndex creates index with the given settings and mappings.
+func (c *Client) CreateIndex(ctx context.Context, index string, body interface{}) error {
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	return c.expectOK(c.do(ctx, http.MethodPut, "/"+url.PathEscape(index), "application/json", data))
+}
+
+// Bulk applies actions to index in one request. Deleting a document that
+// does not exist is not an error.
+func (c *Client) Bulk(ctx context.Context, index string, actions []BulkAction) error {
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+
+	for _, action := range actions {
+		verb := "index"
+		if action.Delete {
+			verb = "delete"
+		}
+
+		meta := map[string]map[string]string{verb: {"_id": action.ID}}
+		if err := encoder.Encode(meta); err != nil {
+			return err
+		}
+
+		if !action.Delete {
+			if err := encoder.Encode(action.Doc); err != nil {
+				return err
+			}
+		}
+	}
+
+	status, body, err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_bulk", "application/x-ndjson", buf.Bytes())
+	if err := c.expectOK(status, body, err); err != nil {
+		return err
+	}
+
+	var res struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+
+	if err := json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+
+	if !res.Errors {
+		return nil
+	}
+
+	for _, item := range res.Items {
+		for verb, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			if verb == "delete" && result.Status == http.StatusNotFound {
+				continue
+			}
+			return &Error{Status: result.Status, Type: result.Error.Type, Reason: result.Error.Reason}
+		}
+	}
+
+	return nil
+}
+
+// DeleteByQuery removes every document of index matching query and returns
+// how many were deleted.
+func (c *Client) DeleteByQuery(ctx context.Context, index string, query interface{}) (int64, error) {
+
+	data, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return 0, err
+	}
+
+	status, body, err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_delete_by_query?conflicts=proceed", "application/json", data)
+	if err := c.expectOK(status, body, err); err != nil {
+		return 0, err
+	}
+
+	var res struct {
+		Deleted int64 `json:"deleted"`
+	}
+
+	if err := json.Unmarshal(body, &res); err != nil {
+		return 0, err
+	}
+
+	return res.Deleted, nil
+}
+
+func (c *Client) Search(ctx context.Context, index string, body interface{}) (*SearchResult, error) {
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	status, resBody, err := c.do(ctx, http.MethodPost, "/"+url.PathEscape(index)+"/_search", "application/json", data)
+	if err := c.expectOK(status, resBody, err); err != nil {
+		return nil, err
+	}
+
+	var res SearchResult
+	if err := json.Unmarshal(resBody, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body []byte) (int, []byte, error) {
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseUrl+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return res.StatusCode, data, nil
+}
+
+func (c *Client) expectOK(status int, body []byte, err error) error {
+
+	if err != nil {
+		return err
+	}
+
+	if status >= 200 && status < 300 {
+		return nil
+	}
+
+	var res struct {
+		Error struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+
+	_ = json.Unmarshal(body, &res)
+
+	return &Error{Status: status, Type: res.Error.Type, Reason: res.Error.Reason}
+}
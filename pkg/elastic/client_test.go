@@ -0,0 +1,351 @@
+package elastic
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordedRequest struct {
+	Method      string
+	Path        string
+	Query       string
+	ContentType string
+	Body        []byte
+	Username    string
+	Password    string
+}
+
+// newTestServer starts a server that records every request and answers with
+// handler.
+func newTestServer(t *testing.T, handler func(w http.ResponseWriter, r *recordedRequest)) (*Client, *[]recordedRequest) {
+	t.Helper()
+
+	var requests []recordedRequest
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		username, password, _ := r.BasicAuth()
+
+		req := recordedRequest{
+			Method:      r.Method,
+			Path:        r.URL.Path,
+			Query:       r.URL.RawQuery,
+			ContentType: r.Header.Get("Content-Type"),
+			Body:        body,
+			Username:    username,
+			Password:    password,
+		}
+		requests = append(requests, req)
+
+		handler(w, &req)
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL+"/", "elastic", "secret"), &requests
+}
+
+func writeJSON(w http.ResponseWriter, status int, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, body)
+}
+
+// ndjsonLines decodes a bulk request body into its lines.
+func ndjsonLines(t *testing.T, body []byte) []map[string]interface{} {
+	t.Helper()
+
+	var lines []map[string]interface{}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(body)))
+	for scanner.Scan() {
+		var line map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("bulk line %q: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func TestIndexExists(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr bool
+	}{
+		{name: "exists", status: http.StatusOK, want: true},
+		{name: "missing", status: http.StatusNotFound, want: false},
+		{name: "error", status: http.StatusInternalServerError, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+				w.WriteHeader(tt.status)
+			})
+
+			exists, err := client.IndexExists(context.Background(), "products")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IndexExists error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if exists != tt.want {
+				t.Errorf("IndexExists = %v, want %v", exists, tt.want)
+			}
+
+			req := (*requests)[0]
+			if req.Method != http.MethodHead || req.Path != "/products" {
+				t.Errorf("request = %s %s, want HEAD /products", req.Method, req.Path)
+			}
+			if req.Username != "elastic" || req.Password != "secret" {
+				t.Errorf("basic auth = %q/%q, want elastic/secret", req.Username, req.Password)
+			}
+		})
+	}
+}
+
+func TestCreateIndex(t *testing.T) {
+
+	client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusOK, `{"acknowledged":true}`)
+	})
+
+	body := map[string]interface{}{"mappings": map[string]interface{}{"properties": map[string]interface{}{}}}
+	if err := client.CreateIndex(context.Background(), "products", body); err != nil {
+		t.Fatal(err)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodPut || req.Path != "/products" {
+		t.Errorf("request = %s %s, want PUT /products", req.Method, req.Path)
+	}
+	if req.ContentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", req.ContentType)
+	}
+	if !strings.Contains(string(req.Body), `"mappings"`) {
+		t.Errorf("body = %s, want the index mappings", req.Body)
+	}
+}
+
+func TestCreateIndexError(t *testing.T) {
+
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusBadRequest, `{"error":{"type":"resource_already_exists_exception","reason":"index [products] already exists"}}`)
+	})
+
+	err := client.CreateIndex(context.Background(), "products", map[string]interface{}{})
+
+	var esErr *Error
+	if !errors.As(err, &esErr) {
+		t.Fatalf("CreateIndex error = %v, want *Error", err)
+	}
+	if esErr.Status != http.StatusBadRequest || esErr.Type != "resource_already_exists_exception" {
+		t.Errorf("error = %+v, want status 400 resource_already_exists_exception", esErr)
+	}
+}
+
+func TestBulk(t *testing.T) {
+
+	client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusOK, `{"errors":false,"items":[{"index":{"status":201}},{"delete":{"status":200}}]}`)
+	})
+
+	actions := []BulkAction{
+		{ID: "1", Doc: map[string]string{"product_name": "Lamp"}},
+		{ID: "2", Delete: true, Doc: map[string]string{"ignored": "yes"}},
+	}
+
+	if err := client.Bulk(context.Background(), "products", actions); err != nil {
+		t.Fatal(err)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/products/_bulk" {
+		t.Errorf("request = %s %s, want POST /products/_bulk", req.Method, req.Path)
+	}
+	if req.ContentType != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", req.ContentType)
+	}
+
+	lines := ndjsonLines(t, req.Body)
+	if len(lines) != 3 {
+		t.Fatalf("bulk body has %d lines, want 3: %s", len(lines), req.Body)
+	}
+
+	index, ok := lines[0]["index"].(map[string]interface{})
+	if !ok || index["_id"] != "1" {
+		t.Errorf("line 1 = %v, want index of _id 1", lines[0])
+	}
+	if lines[1]["product_name"] != "Lamp" {
+		t.Errorf("line 2 = %v, want the document", lines[1])
+	}
+	del, ok := lines[2]["delete"].(map[string]interface{})
+	if !ok || del["_id"] != "2" {
+		t.Errorf("line 3 = %v, want delete of _id 2", lines[2])
+	}
+}
+
+func TestBulkEmpty(t *testing.T) {
+
+	client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusOK, `{"errors":false,"items":[]}`)
+	})
+
+	if err := client.Bulk(context.Background(), "products", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 0 {
+		t.Errorf("sent %d requests for an empty bulk, want 0", len(*requests))
+	}
+}
+
+func TestBulkItemErrors(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		response   string
+		wantErr    bool
+		wantStatus int
+	}{
+		{
+			name:     "delete of missing document",
+			response: `{"errors":true,"items":[{"delete":{"status":404,"error":{"type":"not_found","reason":"missing"}}}]}`,
+		},
+		{
+			name:       "index failure",
+			response:   `{"errors":true,"items":[{"index":{"status":201}},{"index":{"status":400,"error":{"type":"mapper_parsing_exception","reason":"bad price"}}}]}`,
+			wantErr:    true,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "delete failure",
+			response:   `{"errors":true,"items":[{"delete":{"status":429,"error":{"type":"es_rejected_execution_exception","reason":"queue full"}}}]}`,
+			wantErr:    true,
+			wantStatus: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			client, _ := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+				writeJSON(w, http.StatusOK, tt.response)
+			})
+
+			err := client.Bulk(context.Background(), "products", []BulkAction{{ID: "1", Delete: true}})
+			if !tt.wantErr {
+				if err != nil {
+					t.Fatalf("Bulk error = %v, want nil", err)
+				}
+				return
+			}
+
+			var esErr *Error
+			if !errors.As(err, &esErr) {
+				t.Fatalf("Bulk error = %v, want *Error", err)
+			}
+			if esErr.Status != tt.wantStatus {
+				t.Errorf("error status = %d, want %d", esErr.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestDeleteByQuery(t *testing.T) {
+
+	client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusOK, `{"deleted":7}`)
+	})
+
+	query := map[string]interface{}{"range": map[string]interface{}{"indexed_at": map[string]interface{}{"lt": "2026-01-01T00:00:00Z"}}}
+
+	deleted, err := client.DeleteByQuery(context.Background(), "products", query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 7 {
+		t.Errorf("deleted = %d, want 7", deleted)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/products/_delete_by_query" || req.Query != "conflicts=proceed" {
+		t.Errorf("request = %s %s?%s, want POST /products/_delete_by_query?conflicts=proceed", req.Method, req.Path, req.Query)
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["query"].(map[string]interface{})["range"]; !ok {
+		t.Errorf("body = %s, want the query under \"query\"", req.Body)
+	}
+}
+
+func TestSearch(t *testing.T) {
+
+	client, requests := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusOK, `{
+			"hits": {
+				"total": {"value": 42},
+				"hits": [
+					{"_id": "a", "_score": 3.5, "highlight": {"product_name": ["<em>Lamp</em>"]}},
+					{"_id": "b", "_score": 1.25, "_source": {"product_name": "Desk"}}
+				]
+			}
+		}`)
+	})
+
+	res, err := client.Search(context.Background(), "products", map[string]interface{}{"size": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := (*requests)[0]
+	if req.Method != http.MethodPost || req.Path != "/products/_search" {
+		t.Errorf("request = %s %s, want POST /products/_search", req.Method, req.Path)
+	}
+
+	if res.Hits.Total.Value != 42 {
+		t.Errorf("total = %d, want 42", res.Hits.Total.Value)
+	}
+	if len(res.Hits.Hits) != 2 {
+		t.Fatalf("got %d hits, want 2", len(res.Hits.Hits))
+	}
+	if hit := res.Hits.Hits[0]; hit.ID != "a" || hit.Score != 3.5 || hit.Highlight["product_name"][0] != "<em>Lamp</em>" {
+		t.Errorf("hit 0 = %+v", hit)
+	}
+	if hit := res.Hits.Hits[1]; hit.ID != "b" || !strings.Contains(string(hit.Source), "Desk") {
+		t.Errorf("hit 1 = %+v", hit)
+	}
+}
+
+func TestSearchError(t *testing.T) {
+
+	client, _ := newTestServer(t, func(w http.ResponseWriter, r *recordedRequest) {
+		writeJSON(w, http.StatusNotFound, `{"error":{"type":"index_not_found_exception","reason":"no such index [products]"}}`)
+	})
+
+	_, err := client.Search(context.Background(), "products", map[string]interface{}{})
+
+	var esErr *Error
+	if !errors.As(err, &esErr) {
+		t.Fatalf("Search error = %v, want *Error", err)
+	}
+	if esErr.Status != http.StatusNotFound || esErr.Type != "index_not_found_exception" {
+		t.Errorf("error = %+v, want status 404 index_not_found_exception", esErr)
+	}
+}
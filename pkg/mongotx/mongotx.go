@@ -0,0 +1,72 @@
+package mongotx
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Runner executes a function inside a multi-document transaction. Repository
+// calls made with the context passed to fn join the transaction. Transactions
+// need MongoDB running as a replica set or sharded cluster.
+type Runner interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type runner struct {
+	client *mongo.Client
+}
+
+func NewRunner(client *mongo.Client) Runner {
+	return &runner{
+		client: client,
+	}
+}
+
+// WithTransaction commits when fn returns nil and aborts otherwise. fn may
+// be retried on transient errors, so it must not keep state between calls.
+func (r *runner) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	session, err := r.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+
+	return err
+}
+
+type directRunner struct{}
+
+// NewDirectRunner returns a Runner that calls fn without a transaction, for
+// writes that are single-document or do not need to be atomic, and for
+// standalone servers that cannot run transactions.
+func NewDirectRunner() Runner {
+	return directRunner{}
+}
+
+func (directRunner) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Supported reports whether the server behind client can run transactions,
+// that is whether it is a replica set member or a mongos.
+func Supported(ctx context.Context, client *mongo.Client) (bool, error) {
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+
+	err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello)
+	if err != nil {
+		return false, err
+	}
+
+	return hello.SetName != "" || hello.Msg == "isdbgrid", nil
+}
@@ -0,0 +1,55 @@
+package qr
+
+import (
+	"errors"
+	"regexp"
+)
+
+const (
+	payloadPrefix = "SENBOX.ORG"
+
+	TypeProduct = "PRODUCT"
+)
+
+var (
+	ErrInvalidPayload = errors.New("invalid qr code payload")
+
+	payloadPattern = regexp.MustCompile(`^SENBOX\.ORG\[([A-Z_]+)\]:([^:]+)(?::(v[0-9]+)\.([A-Za-z0-9_-]+))?$`)
+)
+
+// Payload is the decoded content of a SENBOX.ORG[<TYPE>]:<value> code. Signed
+// codes carry a trailing ":<version>.<signature>".
+type Payload struct {
+	Type      string
+	Value     string
+	Version   string
+	Signature string
+}
+
+func (p *Payload) Signed() bool {
+	return p.Signature != ""
+}
+
+// ProductPayload returns the unsigned content of a product's QR code.
+func ProductPayload(id string) string {
+	return NewPayload(TypeProduct, id)
+}
+
+func NewPayload(kind string, value string) string {
+	return payloadPrefix + "[" + kind + "]:" + value
+}
+
+func ParsePayload(content string) (*Payload, error) {
+
+	match := payloadPattern.FindStringSubmatch(content)
+	if match == nil {
+		return nil, ErrInvalidPayload
+	}
+
+	return &Payload{
+		Type:      match[1],
+		Value:     match[2],
+		Version:   match[3],
+		Signature: match[4],
+	}, nil
+}
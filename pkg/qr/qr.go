@@ -0,0 +1,242 @@
+package qr
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+
+	DefaultSize   = 256
+	DefaultMargin = 4
+	DefaultECC    = "M"
+
+	MinSize   = 64
+	MaxSize   = 2048
+	MaxMargin = 16
+)
+
+var ErrInvalidOptions = errors.New("invalid qr code options")
+
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Options controls how a QR code is rendered. Size is the image width and
+// height in pixels, Margin the quiet zone in modules and ECC one of the
+// error correction levels L, M, Q or H.
+type Options struct {
+	Format string
+	Size   int
+	ECC    string
+	Margin int
+}
+
+// Code is a QR code ready to render. Its ETag is known before the image is
+// drawn, so conditional requests can be answered without rendering.
+type Code struct {
+	Content string
+	Options Options
+	ETag    string
+}
+
+// Image is a rendered QR code.
+type Image struct {
+	Data        []byte
+	ContentType string
+	ETag        string
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Format: FormatPNG,
+		Size:   DefaultSize,
+		ECC:    DefaultECC,
+		Margin: DefaultMargin,
+	}
+}
+
+// Normalize fills in defaults and rejects out of range values.
+func (o Options) Normalize() (Options, error) {
+
+	if o.Format == "" {
+		o.Format = FormatPNG
+	}
+	o.Format = strings.ToLower(o.Format)
+
+	if o.Format != FormatPNG && o.Format != FormatSVG {
+		return o, fmt.Errorf("%w: unsupported format %s", ErrInvalidOptions, o.Format)
+	}
+
+	if o.Size == 0 {
+		o.Size = DefaultSize
+	}
+
+	if o.Size < MinSize || o.Size > MaxSize {
+		return o, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	}
+
+	if o.ECC == "" {
+		o.ECC = DefaultECC
+	}
+	o.ECC = strings.ToUpper(o.ECC)
+
+	if _, ok := levels[o.ECC]; !ok {
+		return o, fmt.Errorf("%w: ecc must be one of L, M, Q or H", ErrInvalidOptions)
+	}
+
+	if o.Margin < 0 || o.Margin > MaxMargin {
+		return o, fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+
+	return o, nil
+}
+
+// New validates opts and prepares content for rendering.
+func New(content string, opts Options) (*Code, error) {
+
+	opts, err := opts.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	code := &Code{
+		Content: content,
+		Options: opts,
+		ETag:    ETag(content, opts),
+	}
+
+	return code, nil
+}
+
+// Encode renders content as a QR code image.
+func Encode(content string, opts Options) (*Image, error) {
+
+	code, err := New(content, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return code.Render()
+}
+
+// Render encodes the content and draws it in the requested format.
+func (c *Code) Render() (*Image, error) {
+
+	code, err := qrcode.New(c.Content, levels[c.Options.ECC])
+	if err != nil {
+		return nil, err
+	}
+
+	code.DisableBorder = true
+	modules := code.Bitmap()
+
+	img := &Image{ETag: c.ETag}
+
+	switch c.Options.Format {
+	case FormatSVG:
+		img.Data = renderSVG(modules, c.Options.Size, c.Options.Margin)
+		img.ContentType = "image/svg+xml"
+	default:
+		img.Data, err = renderPNG(modules, c.Options.Size, c.Options.Margin)
+		if err != nil {
+			return nil, err
+		}
+		img.ContentType = "image/png"
+	}
+
+	return img, nil
+}
+
+// ETag identifies the image rendered for content and normalized opts.
+func ETag(content string, opts Options) string {
+
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%s|%d", content, opts.Format, opts.Size, opts.ECC, opts.Margin)))
+
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// renderPNG draws the modules on a size x size canvas. Each module gets the
+// same whole number of pixels and any remainder is split around the code.
+func renderPNG(modules [][]bool, size int, margin int) ([]byte, error) {
+
+	total := len(modules) + 2*margin
+	scale := size / total
+	if scale < 1 {
+		return nil, fmt.Errorf("%w: size %d is too small for a %d module code", ErrInvalidOptions, size, total)
+	}
+
+	offset := (size-scale*total)/2 + margin*scale
+
+	palette := color.Palette{color.White, color.Black}
+	canvas := image.NewPaletted(image.Rect(0, 0, size, size), palette)
+
+	for y, row := range modules {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					canvas.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+
+	encoder := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := encoder.Encode(&buf, canvas); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderSVG emits one path made of horizontal runs of dark modules, in a
+// viewBox measured in modules so the image scales without blurring.
+func renderSVG(modules [][]bool, size int, margin int) []byte {
+
+	total := len(modules) + 2*margin
+
+	var path strings.Builder
+
+	for y, row := range modules {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start+margin, y+margin, x-start, x-start)
+		}
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, size, size, total, total)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#fff"/>`, total, total)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000"/>`, path.String())
+	buf.WriteString(`</svg>`)
+
+	return buf.Bytes()
+}
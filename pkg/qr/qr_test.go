@@ -0,0 +1,312 @@
+package qr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/png"
+	"strings"
+	"testing"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const testContent = "https://example.com/p/665f1c2a9d1e8b0012345678"
+
+// wantModules is the module matrix the renderers are expected to draw.
+func wantModules(t *testing.T, content string, ecc string) [][]bool {
+	t.Helper()
+
+	code, err := qrcode.New(content, levels[ecc])
+	if err != nil {
+		t.Fatal(err)
+	}
+	code.DisableBorder = true
+
+	return code.Bitmap()
+}
+
+func TestNormalize(t *testing.T) {
+
+	tests := []struct {
+		name    string
+		opts    Options
+		want    Options
+		wantErr bool
+	}{
+		{name: "defaults", opts: Options{Margin: DefaultMargin}, want: DefaultOptions()},
+		{name: "case folded", opts: Options{Format: "SVG", ECC: "q", Size: 300, Margin: 2}, want: Options{Format: FormatSVG, ECC: "Q", Size: 300, Margin: 2}},
+		{name: "unknown format", opts: Options{Format: "gif"}, wantErr: true},
+		{name: "too small", opts: Options{Size: MinSize - 1}, wantErr: true},
+		{name: "too large", opts: Options{Size: MaxSize + 1}, wantErr: true},
+		{name: "unknown ecc", opts: Options{ECC: "X"}, wantErr: true},
+		{name: "negative margin", opts: Options{Margin: -1}, wantErr: true},
+		{name: "margin too large", opts: Options{Margin: MaxMargin + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got, err := tt.opts.Normalize()
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidOptions) {
+					t.Fatalf("Normalize error = %v, want ErrInvalidOptions", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodePNG(t *testing.T) {
+
+	for _, margin := range []int{0, DefaultMargin} {
+		t.Run(fmt.Sprintf("margin %d", margin), func(t *testing.T) {
+
+			opts := Options{Format: FormatPNG, Size: 300, ECC: "H", Margin: margin}
+
+			img, err := Encode(testContent, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if img.ContentType != "image/png" {
+				t.Errorf("ContentType = %q, want image/png", img.ContentType)
+			}
+
+			decoded, err := png.Decode(bytes.NewReader(img.Data))
+			if err != nil {
+				t.Fatalf("decoding PNG: %v", err)
+			}
+
+			bounds := decoded.Bounds()
+			if bounds.Dx() != opts.Size || bounds.Dy() != opts.Size {
+				t.Fatalf("image is %dx%d, want %dx%d", bounds.Dx(), bounds.Dy(), opts.Size, opts.Size)
+			}
+
+			modules := wantModules(t, testContent, opts.ECC)
+			total := len(modules) + 2*margin
+			scale := opts.Size / total
+			offset := (opts.Size-scale*total)/2 + margin*scale
+
+			// Sample the centre of every module.
+			for y, row := range modules {
+				for x, dark := range row {
+					r, _, _, _ := decoded.At(offset+x*scale+scale/2, offset+y*scale+scale/2).RGBA()
+					if gotDark := r == 0; gotDark != dark {
+						t.Fatalf("module (%d, %d) dark = %v, want %v", x, y, gotDark, dark)
+					}
+				}
+			}
+
+			// The quiet zone and the corners stay white.
+			for _, p := range [][2]int{{0, 0}, {opts.Size - 1, opts.Size - 1}, {offset - 1, offset - 1}} {
+				if r, _, _, _ := decoded.At(p[0], p[1]).RGBA(); r == 0 {
+					t.Errorf("pixel %v is dark, want the quiet zone white", p)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodePNGTooSmall(t *testing.T) {
+
+	content := strings.Repeat("x", 1000)
+
+	_, err := Encode(content, Options{Format: FormatPNG, Size: MinSize, ECC: "H", Margin: MaxMargin})
+	if !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("Encode error = %v, want ErrInvalidOptions", err)
+	}
+}
+
+type svgDocument struct {
+	Width   string `xml:"width,attr"`
+	Height  string `xml:"height,attr"`
+	ViewBox string `xml:"viewBox,attr"`
+	Rect    struct {
+		Fill string `xml:"fill,attr"`
+	} `xml:"rect"`
+	Path struct {
+		D    string `xml:"d,attr"`
+		Fill string `xml:"fill,attr"`
+	} `xml:"path"`
+}
+
+// svgModules replays the horizontal runs of an SVG path onto a grid of
+// size x size modules.
+func svgModules(t *testing.T, d string, size int) [][]bool {
+	t.Helper()
+
+	grid := make([][]bool, size)
+	for i := range grid {
+		grid[i] = make([]bool, size)
+	}
+
+	for _, run := range strings.Split(d, "z") {
+		if run == "" {
+			continue
+		}
+
+		var x, y, width, back int
+		if _, err := fmt.Sscanf(run, "M%d %dh%dv1h-%d", &x, &y, &width, &back); err != nil {
+			t.Fatalf("parsing path run %q: %v", run, err)
+		}
+		if back != width {
+			t.Fatalf("path run %q does not close", run)
+		}
+
+		for i := x; i < x+width; i++ {
+			grid[y][i] = true
+		}
+	}
+
+	return grid
+}
+
+func TestEncodeSVG(t *testing.T) {
+
+	opts := Options{Format: FormatSVG, Size: 512, ECC: "M", Margin: 2}
+
+	img, err := Encode(testContent, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if img.ContentType != "image/svg+xml" {
+		t.Errorf("ContentType = %q, want image/svg+xml", img.ContentType)
+	}
+
+	var doc svgDocument
+	if err := xml.Unmarshal(img.Data, &doc); err != nil {
+		t.Fatalf("decoding SVG: %v", err)
+	}
+
+	modules := wantModules(t, testContent, opts.ECC)
+	total := len(modules) + 2*opts.Margin
+
+	if doc.Width != "512" || doc.Height != "512" {
+		t.Errorf("svg is %sx%s, want 512x512", doc.Width, doc.Height)
+	}
+	if want := fmt.Sprintf("0 0 %d %d", total, total); doc.ViewBox != want {
+		t.Errorf("viewBox = %q, want %q", doc.ViewBox, want)
+	}
+	if doc.Rect.Fill != "#fff" || doc.Path.Fill != "#000" {
+		t.Errorf("fills = %q/%q, want #fff/#000", doc.Rect.Fill, doc.Path.Fill)
+	}
+
+	grid := svgModules(t, doc.Path.D, total)
+	for y := 0; y < total; y++ {
+		for x := 0; x < total; x++ {
+			want := false
+			if my, mx := y-opts.Margin, x-opts.Margin; my >= 0 && my < len(modules) && mx >= 0 && mx < len(modules) {
+				want = modules[my][mx]
+			}
+			if grid[y][x] != want {
+				t.Fatalf("module (%d, %d) dark = %v, want %v", x, y, grid[y][x], want)
+			}
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+
+	base := Options{Format: FormatPNG, Size: 256, ECC: "M", Margin: 4}
+
+	code, err := New(testContent, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("stable", func(t *testing.T) {
+
+		again, err := New(testContent, base)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again.ETag != code.ETag {
+			t.Errorf("ETag changed between calls: %s then %s", code.ETag, again.ETag)
+		}
+		if !strings.HasPrefix(code.ETag, `"`) || !strings.HasSuffix(code.ETag, `"`) {
+			t.Errorf("ETag %s is not quoted", code.ETag)
+		}
+	})
+
+	t.Run("matches the rendered image", func(t *testing.T) {
+
+		for _, format := range []string{FormatPNG, FormatSVG} {
+			opts := base
+			opts.Format = format
+
+			code, err := New(testContent, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			img, err := code.Render()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if img.ETag != code.ETag {
+				t.Errorf("%s image ETag = %s, want %s", format, img.ETag, code.ETag)
+			}
+
+			encoded, err := Encode(testContent, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encoded.Data, img.Data) {
+				t.Errorf("%s rendering is not deterministic", format)
+			}
+		}
+	})
+
+	t.Run("equivalent options", func(t *testing.T) {
+
+		equivalent, err := New(testContent, Options{Format: "PNG", ECC: "m", Margin: 4})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if equivalent.ETag != code.ETag {
+			t.Errorf("defaulted options ETag = %s, want %s", equivalent.ETag, code.ETag)
+		}
+	})
+
+	changes := []struct {
+		name    string
+		content string
+		opts    Options
+	}{
+		{name: "content", content: testContent + "x", opts: base},
+		{name: "format", content: testContent, opts: Options{Format: FormatSVG, Size: 256, ECC: "M", Margin: 4}},
+		{name: "size", content: testContent, opts: Options{Format: FormatPNG, Size: 512, ECC: "M", Margin: 4}},
+		{name: "ecc", content: testContent, opts: Options{Format: FormatPNG, Size: 256, ECC: "H", Margin: 4}},
+		{name: "margin", content: testContent, opts: Options{Format: FormatPNG, Size: 256, ECC: "M", Margin: 1}},
+	}
+
+	for _, tt := range changes {
+		t.Run("differs by "+tt.name, func(t *testing.T) {
+
+			other, err := New(tt.content, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if other.ETag == code.ETag {
+				t.Errorf("ETag did not change with the %s", tt.name)
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+
+	if _, err := New(testContent, Options{Format: "gif"}); !errors.Is(err, ErrInvalidOptions) {
+		t.Errorf("New error = %v, want ErrInvalidOptions", err)
+	}
+}
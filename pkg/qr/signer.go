@@ -0,0 +1,103 @@
+package qr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	signatureVersion = "v1"
+	signatureBytes   = 12
+)
+
+var (
+	ErrInvalidSignature = errors.New("qr code signature is invalid")
+	ErrUnsignedPayload  = errors.New("unsigned qr codes are no longer accepted")
+)
+
+// Signer appends a short HMAC to payloads so codes for arbitrary ids cannot
+// be printed without the key, and verifies scanned codes. While signing is
+// enabled, unsigned legacy codes stay valid until legacyUntil and are
+// rejected after it.
+type Signer struct {
+	key         []byte
+	enabled     bool
+	legacyUntil time.Time
+	now         func() time.Time
+}
+
+// NewSigner returns a signer that signs new payloads when enabled is true.
+// Signed payloads can be verified whenever a key is set.
+func NewSigner(key string, enabled bool, legacyUntil time.Time) (*Signer, error) {
+
+	if enabled && key == "" {
+		return nil, errors.New("qr signing is enabled but no signing key is configured")
+	}
+
+	if enabled && legacyUntil.IsZero() {
+		return nil, errors.New("qr signing is enabled but no end of the legacy grace period is configured")
+	}
+
+	return &Signer{
+		key:         []byte(key),
+		enabled:     enabled,
+		legacyUntil: legacyUntil,
+		now:         time.Now,
+	}, nil
+}
+
+// Payload returns the content to encode for kind and value, signed when
+// signing is enabled.
+func (s *Signer) Payload(kind string, value string) string {
+
+	if !s.enabled {
+		return NewPayload(kind, value)
+	}
+
+	return NewPayload(kind, value) + ":" + signatureVersion + "." + s.signature(signatureVersion, kind, value)
+}
+
+func (s *Signer) ProductPayload(id string) string {
+	return s.Payload(TypeProduct, id)
+}
+
+// Verify checks the signature of a parsed payload, or for an unsigned one
+// whether legacy codes are still accepted.
+func (s *Signer) Verify(p *Payload) error {
+
+	if !p.Signed() {
+		if s.enabled && !s.now().Before(s.legacyUntil) {
+			return ErrUnsignedPayload
+		}
+		return nil
+	}
+
+	if len(s.key) == 0 {
+		return fmt.Errorf("%w: no signing key is configured", ErrInvalidSignature)
+	}
+
+	if p.Version != signatureVersion {
+		return fmt.Errorf("%w: unsupported version %s", ErrInvalidSignature, p.Version)
+	}
+
+	expected := s.signature(p.Version, p.Type, p.Value)
+	if !hmac.Equal([]byte(expected), []byte(p.Signature)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// signature covers the version, type and value so a signature cannot be
+// moved to another code.
+func (s *Signer) signature(version string, kind string, value string) string {
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(version + "|" + kind + "|" + value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:signatureBytes])
+}
@@ -0,0 +1,33 @@
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Fold strips diacritics so Vietnamese and other accented text can be
+// rendered with plain Latin fonts or compared loosely: "Đồ chơi" becomes
+// "Do choi". Letters without a decomposed form, such as đ, are mapped
+// explicitly.
+func Fold(s string) string {
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			b.WriteRune('d')
+		case r == 'Đ':
+			b.WriteRune('D')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
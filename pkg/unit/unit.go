@@ -0,0 +1,71 @@
+package unit
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type definition struct {
+	base   string
+	factor float64
+}
+
+// units maps every known unit to its base unit for the dimension it measures.
+var units = map[string]definition{
+	"mg": {"g", 0.001},
+	"g":  {"g", 1},
+	"kg": {"g", 1000},
+	"t":  {"g", 1000000},
+
+	"mm": {"m", 0.001},
+	"cm": {"m", 0.01},
+	"dm": {"m", 0.1},
+	"m":  {"m", 1},
+	"km": {"m", 1000},
+
+	"ml": {"ml", 1},
+	"cl": {"ml", 10},
+	"dl": {"ml", 100},
+	"l":  {"ml", 1000},
+
+	"ms":  {"s", 0.001},
+	"s":   {"s", 1},
+	"min": {"s", 60},
+	"h":   {"s", 3600},
+}
+
+// Normalize converts value to the base unit of its dimension. Unknown units
+// are returned unchanged so they still compare against values of the same unit.
+func Normalize(value float64, unit string) (float64, string) {
+
+	unit = strings.ToLower(strings.TrimSpace(unit))
+
+	def, ok := units[unit]
+	if !ok {
+		return value, unit
+	}
+
+	return value * def.factor, def.base
+}
+
+// Parse splits a quantity such as "2kg" or "1.5 m" into its number and unit.
+func Parse(raw string) (float64, string, error) {
+
+	raw = strings.TrimSpace(raw)
+
+	end := strings.IndexFunc(raw, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+	})
+	if end == -1 {
+		end = len(raw)
+	}
+
+	value, err := strconv.ParseFloat(raw[:end], 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid quantity: %s", raw)
+	}
+
+	return value, strings.TrimSpace(raw[end:]), nil
+}